@@ -8,6 +8,8 @@ require (
 	github.com/creack/pty v1.1.24
 	github.com/deckarep/golang-set/v2 v2.8.0
 	github.com/openai/openai-go/v2 v2.4.0
+	golang.org/x/crypto v0.41.0
+	golang.org/x/sys v0.35.0
 	golang.org/x/term v0.34.0
 	gopkg.in/yaml.v3 v3.0.1
 )
@@ -17,5 +19,4 @@ require (
 	github.com/tidwall/match v1.1.1 // indirect
 	github.com/tidwall/pretty v1.2.1 // indirect
 	github.com/tidwall/sjson v1.2.5 // indirect
-	golang.org/x/sys v0.35.0 // indirect
 )
@@ -9,16 +9,24 @@ import (
 	"github.com/openai/openai-go/v2/option"
 )
 
+// ErrNoAPIKey is returned by New when no API key is configured.
+var ErrNoAPIKey = errors.New("OPENAI_API_KEY environment variable is not set")
+
 type Client struct {
-	sdk openai.Client
+	sdk         openai.Client
+	model       string
+	temperature float64
 }
 
-func New(apiKey string) (*Client, error) {
+func New(apiKey, model string, temperature float64) (*Client, error) {
 	if strings.TrimSpace(apiKey) == "" {
-		return nil, errors.New("OPENAI_API_KEY environment variable is not set")
+		return nil, ErrNoAPIKey
+	}
+	if strings.TrimSpace(model) == "" {
+		model = openai.ChatModelGPT4oMini
 	}
 	client := openai.NewClient(option.WithAPIKey(apiKey))
-	return &Client{sdk: client}, nil
+	return &Client{sdk: client, model: model, temperature: temperature}, nil
 }
 
 func (c *Client) Ask(ctx context.Context, userQuestion string, systemMessage string) (string, error) {
@@ -27,7 +35,8 @@ func (c *Client) Ask(ctx context.Context, userQuestion string, systemMessage str
 			openai.UserMessage(userQuestion),
 			openai.SystemMessage(systemMessage),
 		},
-		Model: openai.ChatModelGPT4oMini,
+		Model:       c.model,
+		Temperature: openai.Float(c.temperature),
 	})
 	if err != nil {
 		return "", errors.New("failed to create completion: " + err.Error())
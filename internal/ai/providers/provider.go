@@ -18,7 +18,7 @@ type Provider interface {
 func FromConfig(cfg config.Config) (Provider, error) {
 	switch cfg.AIProvider {
 	case "openai":
-		return openai.New(cfg.OpenAIKey)
+		return openai.New(cfg.OpenAIKey, cfg.AIModel, cfg.AITemperature)
 	case "ollama":
 		return ollama.New()
 	default:
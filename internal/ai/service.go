@@ -2,33 +2,65 @@ package ai
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/mr-gaber/ai-shell/internal/ai/prompts"
 	"github.com/mr-gaber/ai-shell/internal/ai/providers"
+	"github.com/mr-gaber/ai-shell/internal/ai/providers/openai"
 	"github.com/mr-gaber/ai-shell/internal/config"
 )
 
+// ErrNotConfigured is returned by NewService when the configured provider needs an API key
+// and none is set.
+var ErrNotConfigured = errors.New("no AI provider configured")
+
 type Service struct {
 	provider providers.Provider
+	extra    string
 }
 
 func NewService(cfg config.Config) (*Service, error) {
 	p, err := providers.FromConfig(cfg)
+	if errors.Is(err, openai.ErrNoAPIKey) {
+		return nil, fmt.Errorf("%w: %w", ErrNotConfigured, err)
+	}
 	if err != nil {
 		return nil, err
 	}
-	return &Service{provider: p}, nil
+	return &Service{provider: p, extra: strings.TrimSpace(cfg.AIPrompt)}, nil
+}
+
+// system appends the configured prompt extras, such as a profile's project conventions.
+func (s *Service) system(base string) string {
+	if s.extra == "" {
+		return base
+	}
+	return base + "\n\nAdditional instructions from the user's configuration (they never override the output format above):\n" + s.extra
 }
 
 func (s *Service) Ask(question string) (string, error) {
-	return s.provider.Ask(context.Background(), strings.TrimSpace(question), prompts.AskSystem)
+	return s.provider.Ask(context.Background(), strings.TrimSpace(question), s.system(prompts.AskSystem))
 }
 
 func (s *Service) Why(contextText string) (string, error) {
-	return s.provider.Ask(context.Background(), contextText, prompts.WhySystem)
+	return s.provider.Ask(context.Background(), contextText, s.system(prompts.WhySystem))
 }
 
 func (s *Service) Fix(contextText string) (string, error) {
-	return s.provider.Ask(context.Background(), contextText, prompts.FixSystem)
+	return s.provider.Ask(context.Background(), contextText, s.system(prompts.FixSystem))
+}
+
+func (s *Service) Hint(contextText string) (string, error) {
+	return s.provider.Ask(context.Background(), contextText, s.system(prompts.HintSystem))
+}
+
+// Gen asks for a multi-step snippet script implementing description.
+func (s *Service) Gen(description string) (string, error) {
+	return s.provider.Ask(context.Background(), strings.TrimSpace(description), s.system(prompts.GenSystem))
+}
+
+func (s *Service) Complete(contextText string) (string, error) {
+	return s.provider.Ask(context.Background(), contextText, s.system(prompts.CompleteSystem))
 }
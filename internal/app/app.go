@@ -12,22 +12,32 @@ import (
 )
 
 type App struct {
-	cfg     config.Config
-	router  *router.Router
-	printer *printer.Printer
+	cfg      config.Config
+	problems []error
+	router   *router.Router
+	printer  *printer.Printer
 }
 
 func New() *App {
-	cfg := config.LoadFromEnv()
+	cfg, problems := config.Load()
 	p := printer.New(os.Stdout, os.Stderr)
 	return &App{
-		cfg:     cfg,
-		router:  router.New(cfg, p),
-		printer: p,
+		cfg:      cfg,
+		problems: problems,
+		router:   router.New(cfg, p),
+		printer:  p,
 	}
 }
 
 func (a *App) Run(args []string) error {
+	// Config problems are reported before routing, so they go to stderr even in JSON mode.
+	// The sandbox child stays quiet: its output is the dry-run being previewed.
+	if len(args) < 2 || args[1] != "__sandbox" {
+		for _, err := range a.problems {
+			a.printer.Error(err)
+		}
+	}
+
 	if a.router.Route(args) {
 		return nil
 	}
@@ -0,0 +1,84 @@
+package ai
+
+import (
+	"strings"
+
+	"github.com/mr-gaber/ai-shell/internal/errs"
+	sessiondanger "github.com/mr-gaber/ai-shell/internal/session/danger"
+	"github.com/mr-gaber/ai-shell/internal/ux/markdown"
+)
+
+// runAnswer sends a command taken from an answer through the same guard, confirmation and
+// execution path as ai fix.
+func (h *Handler) runAnswer(command string) {
+	policy, err := sessiondanger.LoadPolicy(h.cfg.Paths.PolicyFile)
+	if err != nil {
+		h.printError(errs.Wrap(err, "danger-policy", "[aish] failed to load danger policy"))
+		return
+	}
+
+	h.info("[aish] Command: " + command)
+	persist, choice := h.approve(policy, command, h.cfg.Fix.DryRun)
+	if h.handOff(command, choice) {
+		return
+	}
+	h.execute(command, persist)
+}
+
+// firstCommand turns the first code block of an answer into a single runnable line: prompt
+// markers and comments are dropped, backslash continuations are joined and separate commands
+// are chained with &&.
+func firstCommand(answer string) string {
+	blocks := markdown.CodeBlocks(answer)
+	if len(blocks) == 0 {
+		return ""
+	}
+
+	var cmds []string
+	pending := ""
+	for _, ln := range strings.Split(blocks[0].Code, "\n") {
+		ln = strings.TrimSpace(ln)
+		if pending == "" {
+			ln = strings.TrimPrefix(ln, "$ ")
+			if ln == "" || strings.HasPrefix(ln, "#") {
+				continue
+			}
+		}
+		if cont, ok := strings.CutSuffix(ln, "\\"); ok {
+			pending += strings.TrimSpace(cont) + " "
+			continue
+		}
+		cmds = append(cmds, strings.TrimSpace(pending+stripComment(ln)))
+		pending = ""
+	}
+	if pending != "" {
+		cmds = append(cmds, strings.TrimSpace(pending))
+	}
+	return strings.Join(cmds, " && ")
+}
+
+// stripComment removes a trailing shell comment so joined commands are not swallowed by it.
+func stripComment(line string) string {
+	var quote rune
+	for i, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return strings.TrimSpace(line[:i])
+		}
+	}
+	return line
+}
+
+func (h *Handler) markdown(msg string) {
+	if h.printer != nil {
+		h.printer.Markdown(msg)
+		return
+	}
+	h.info(msg)
+}
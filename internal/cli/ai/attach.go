@@ -0,0 +1,121 @@
+package ai
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mr-gaber/ai-shell/internal/errs"
+	"github.com/mr-gaber/ai-shell/internal/session/redact"
+)
+
+// fileList collects repeated -f flags.
+type fileList []string
+
+func (f *fileList) String() string { return strings.Join(*f, ",") }
+
+func (f *fileList) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+// attachment is one labelled, redacted input added to an ask prompt.
+type attachment struct {
+	label     string
+	body      string
+	truncated bool
+}
+
+// collectAttachments reads every file plus piped stdin, if any, and redacts them with the user's rules.
+func (h *Handler) collectAttachments(files []string) ([]attachment, error) {
+	redactor, err := redact.Load(h.cfg.Paths.RedactFile)
+	if err != nil {
+		return nil, err
+	}
+
+	max := h.cfg.Limits.AttachMaxBytes
+	out := make([]attachment, 0, len(files)+1)
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, errs.Wrap(err, "ai-attach-open", "[aish] cannot read attachment", errs.WithFields(map[string]string{"file": path}))
+		}
+		a, err := readAttachment(path, f, max)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+
+	if stdinPiped() {
+		a, err := readAttachment("stdin", os.Stdin, max)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(a.body) != "" {
+			out = append(out, a)
+		}
+	}
+
+	for i := range out {
+		out[i].body = redactor.Scrub(out[i].body)
+	}
+	return out, nil
+}
+
+func readAttachment(label string, r io.Reader, max int) (attachment, error) {
+	data, err := io.ReadAll(io.LimitReader(r, int64(max)+1))
+	if err != nil {
+		return attachment{}, errs.Wrap(err, "ai-attach-read", "[aish] cannot read attachment", errs.WithFields(map[string]string{"file": label}))
+	}
+
+	truncated := len(data) > max
+	if truncated {
+		data = trimPartialRune(data[:max])
+	}
+	if isBinary(data) {
+		return attachment{}, errs.New("ai-attach-binary", "[aish] attachment looks binary; only text can be sent", errs.WithFields(map[string]string{"file": label}))
+	}
+	return attachment{label: label, body: string(data), truncated: truncated}, nil
+}
+
+// isBinary treats NUL bytes or invalid UTF-8 in the first 8 KiB as binary content.
+func isBinary(data []byte) bool {
+	head := data
+	if len(head) > 8<<10 {
+		head = trimPartialRune(head[:8<<10])
+	}
+	return bytes.IndexByte(head, 0) >= 0 || !utf8.Valid(head)
+}
+
+// trimPartialRune drops a multi-byte rune split by a cut at the end of data.
+func trimPartialRune(data []byte) []byte {
+	for i := 0; i < utf8.UTFMax-1 && len(data) > 0 && !utf8.Valid(data); i++ {
+		data = data[:len(data)-1]
+	}
+	return data
+}
+
+func stdinPiped() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice == 0
+}
+
+func formatAttachments(atts []attachment, max int) string {
+	var b strings.Builder
+	for i, a := range atts {
+		note := ""
+		if a.truncated {
+			note = fmt.Sprintf(" (truncated to %d bytes)", max)
+		}
+		fmt.Fprintf(&b, "--- BEGIN ATTACHMENT %d: %s%s ---\n%s\n--- END ATTACHMENT %d: %s ---\n", i+1, a.label, note, strings.TrimRight(a.body, "\n"), i+1, a.label)
+	}
+	return b.String()
+}
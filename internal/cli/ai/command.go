@@ -1,6 +1,7 @@
 package ai
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -10,11 +11,7 @@ import (
 	"github.com/mr-gaber/ai-shell/internal/cli/shared"
 	"github.com/mr-gaber/ai-shell/internal/config"
 	"github.com/mr-gaber/ai-shell/internal/errs"
-	sessionbuiltins "github.com/mr-gaber/ai-shell/internal/session/builtins"
 	sessioncontext "github.com/mr-gaber/ai-shell/internal/session/context"
-	sessiondanger "github.com/mr-gaber/ai-shell/internal/session/danger"
-	"github.com/mr-gaber/ai-shell/internal/shell"
-	"github.com/mr-gaber/ai-shell/internal/shell/runner"
 	"github.com/mr-gaber/ai-shell/internal/ux/printer"
 )
 
@@ -24,6 +21,8 @@ type Handler struct {
 	svc     *ainternal.Service
 	svcErr  error
 	printer *printer.Printer
+	// background is set for hint, which must never stop to ask for a passphrase.
+	background bool
 }
 
 func New(cfg config.Config, p *printer.Printer) *Handler {
@@ -33,9 +32,14 @@ func New(cfg config.Config, p *printer.Printer) *Handler {
 func (h *Handler) Handle(args []string) {
 	if len(args) == 0 || args[0] == "help" {
 		shared.PrintUsage(h.printer, `ai usage:
-  ai ask <question> // Ask a question
+  ai ask [-c] [-f file]... [--run] <question> // Ask a question; piped stdin is attached too
   ai why    // Explain the last error
-  ai fix   // Propose a fix for the last error
+  ai fix [--dry-run] [--verify] [--max-iterations N]   // Propose a fix for the last error
+  ai hint  // One-line hint for the last failure (used by the prompt hook)
+  ai complete -- <line>  // Complete or correct a command line (used by the Ctrl-G binding)
+  ai profile  // Show the profile and AI settings in effect for this directory
+
+  ai --output json <subcommand> ... // Print the result or error as JSON (ai fix only proposes)
   `)
 		return
 	}
@@ -46,9 +50,15 @@ func (h *Handler) Handle(args []string) {
 	case "why":
 		h.handleWhy()
 	case "fix":
-		h.handleFix()
+		h.handleFix(args[1:])
+	case "hint":
+		h.handleHint()
+	case "complete":
+		h.handleComplete(args[1:])
+	case "profile":
+		h.handleProfile()
 	default:
-		fmt.Printf("ai: unknown subcommand %q\n", args[0])
+		h.printError(errs.New("ai-unknown-subcommand", fmt.Sprintf("ai: unknown subcommand %q", args[0])))
 	}
 }
 
@@ -57,13 +67,8 @@ func (h *Handler) printError(err error) {
 		return
 	}
 
-	msg := strings.ToLower(err.Error())
-	if strings.Contains(msg, "openai_api_key") || strings.Contains(msg, "no api key") {
-		if h.printer != nil {
-			h.printer.Warn("[aish] No AI configured. Set OPENAI_API_KEY to use 'ai' commands.")
-		} else {
-			fmt.Println("[aish] No AI configured. Set OPENAI_API_KEY to use 'ai' commands.")
-		}
+	if errors.Is(err, ainternal.ErrNotConfigured) && !h.printer.JSON() {
+		h.warn(err.Error())
 		return
 	}
 	if h.printer != nil {
@@ -73,41 +78,59 @@ func (h *Handler) printError(err error) {
 	fmt.Println("ai:", err.Error())
 }
 
+// askResult is the JSON shape of ai ask.
+type askResult struct {
+	Answer      string `json:"answer"`
+	Command     string `json:"command,omitempty"`
+	Attachments int    `json:"attachments"`
+}
+
+// whyResult is the JSON shape of ai why.
+type whyResult struct {
+	Explanation string `json:"explanation"`
+}
+
 func (h *Handler) handleAsk(rest []string) {
+	const usage = "usage: ai ask [-c|--context] [-f file]... [--run] <question>"
+
 	fs := flag.NewFlagSet("ai ask", flag.ContinueOnError)
-	includeContext := fs.Bool("c", false, "include recent command history as context")
-	_ = fs.Bool("context", false, "include recent command history as context")
+	var includeContext bool
+	var files fileList
+	fs.BoolVar(&includeContext, "c", false, "include recent command history as context")
+	fs.BoolVar(&includeContext, "context", false, "include recent command history as context")
+	fs.Var(&files, "f", "attach a file (repeatable)")
+	fs.Var(&files, "file", "attach a file (repeatable)")
+	run := fs.Bool("run", false, "offer to run the first code block of the answer")
 	fs.SetOutput(io.Discard)
 
-	if len(rest) == 0 {
-		h.info("usage: ai ask <question>")
-		return
-	}
 	if err := fs.Parse(rest); err != nil {
-		h.info("usage: ai ask [-c|--context] <question>")
+		h.info(usage)
 		return
 	}
 
-	args := fs.Args()
-	if len(args) == 0 {
-		h.info("usage: ai ask [-c|--context] <question>")
+	question := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if question == "" {
+		h.info(usage)
 		return
 	}
 
-	question := strings.TrimSpace(strings.Join(rest, " "))
-	if question == "" {
-		h.info("usage: ai ask <question>")
+	attachments, err := h.collectAttachments(files)
+	if err != nil {
+		h.printError(err)
 		return
 	}
+	if len(attachments) > 0 {
+		question = fmt.Sprintf("Use the attached input to answer the question.\n%s\nQuestion: %s", formatAttachments(attachments, h.cfg.Limits.AttachMaxBytes), question)
+	}
 
-	if *includeContext {
+	if includeContext {
 		context, _, err := h.buildContext()
 		if err != nil {
 			h.printError(err)
 			return
 		}
 
-		question = fmt.Sprintf("Given the following context from my recent terminal session, answer the question concisely.)\n%s\nQuestion: %s", context, question)
+		question = fmt.Sprintf("Given the following context from my recent terminal session, answer the question concisely.\n%s\n%s", context, question)
 	}
 
 	svc, err := h.service()
@@ -122,31 +145,24 @@ func (h *Handler) handleAsk(rest []string) {
 		return
 	}
 
-	h.info(out)
-}
-
-func (h *Handler) handleWhy() {
-	context, _, err := h.buildContext()
-	if err != nil {
-		h.printError(err)
+	command := firstCommand(out)
+	if h.printer.JSON() {
+		h.printer.Result(askResult{Answer: out, Command: command, Attachments: len(attachments)})
 		return
 	}
+	h.markdown(out)
 
-	svc, err := h.service()
-	if err != nil {
-		h.printError(err)
+	if !*run {
 		return
 	}
-
-	out, err := svc.Why(context)
-	if err != nil {
-		h.printError(err)
+	if command == "" {
+		h.warn("[aish] The answer has no code block to run.")
 		return
 	}
-	h.info(out)
+	h.runAnswer(command)
 }
 
-func (h *Handler) handleFix() {
+func (h *Handler) handleWhy() {
 	context, _, err := h.buildContext()
 	if err != nil {
 		h.printError(err)
@@ -159,47 +175,25 @@ func (h *Handler) handleFix() {
 		return
 	}
 
-	out, err := svc.Fix(context)
+	out, err := svc.Why(context)
 	if err != nil {
 		h.printError(err)
 		return
 	}
-
-	h.info(out)
-
-	command := strings.TrimPrefix(out, "COMMAND: ")
-	command = strings.Split(command, "\n")[0]
-
-	if strings.TrimSpace(command) == "" {
-		h.warn("[aish] No runnable command was suggested.")
-		return
-	}
-	if sessiondanger.IsDangerous(command) {
-		h.warn("[aish] This command looks dangerous; refusing to auto-run.")
-		return
-	}
-	if sessionbuiltins.IsNonPersisting(command) {
-		h.warn("[aish] Note: builtins like 'cd'/'export' won’t change your parent shell; I won’t auto-run them.")
-		return
-	}
-
-	fmt.Print("[aish] Run it now? [y/N] ")
-	yes := shell.ConfirmFromStdin()
-	if !yes {
+	if h.printer.JSON() {
+		h.printer.Result(whyResult{Explanation: out})
 		return
 	}
-	if err := runner.Run(command); err != nil {
-		if h.printer != nil {
-			h.printer.Error(errs.Wrap(err, "ai-run", "[aish] run error"))
-		} else {
-			fmt.Println("[aish] run error:", err)
-		}
-	}
+	h.markdown(out)
 }
 
-func (h *Handler) buildContext() (string, bool, error) {
+func (h *Handler) buildContext() (string, sessioncontext.Builder, error) {
 	builder := sessioncontext.NewBuilder(h.cfg)
-	return builder.Build()
+	context, _, err := builder.Build()
+	if err != nil {
+		return "", builder, err
+	}
+	return context, builder, nil
 }
 
 func (h *Handler) info(msg string) {
@@ -226,7 +220,7 @@ func (h *Handler) service() (*ainternal.Service, error) {
 		return nil, h.svcErr
 	}
 
-	svc, err := ainternal.NewService(h.cfg)
+	svc, err := shared.AIService(&h.cfg, !h.background, "'ai' commands")
 	if err != nil {
 		h.svcErr = err
 		return nil, err
@@ -234,3 +228,11 @@ func (h *Handler) service() (*ainternal.Service, error) {
 	h.svc = svc
 	return svc, nil
 }
+
+func (h *Handler) success(msg string) {
+	if h.printer != nil {
+		h.printer.Success(msg)
+		return
+	}
+	fmt.Println(msg)
+}
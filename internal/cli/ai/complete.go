@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	sessioncontext "github.com/mr-gaber/ai-shell/internal/session/context"
+	"github.com/mr-gaber/ai-shell/internal/ux/printer"
+)
+
+// completeResult is the JSON shape of ai complete.
+type completeResult struct {
+	Line       string `json:"line"`
+	Suggestion string `json:"suggestion"`
+}
+
+// handleComplete backs the prompt-line hotkey: it prints only the suggested command line on
+// stdout, which the shell binding puts back into the editing buffer. An empty line asks for a
+// correction of the last failed command.
+func (h *Handler) handleComplete(rest []string) {
+	if len(rest) > 0 && rest[0] == "--" {
+		rest = rest[1:]
+	}
+	if !h.printer.JSON() {
+		// Diagnostics must not end up in the buffer the binding captures from stdout.
+		h.printer = printer.New(os.Stderr, os.Stderr)
+	}
+
+	builder := sessioncontext.NewBuilder(h.cfg)
+	line := strings.TrimSpace(strings.Join(rest, " "))
+	if line == "" {
+		last, err := builder.LastCommand()
+		if err != nil || last.Exit == 0 {
+			return
+		}
+		line = last.Cmd
+	}
+
+	prompt := fmt.Sprintf("Command line: %s\n", builder.Scrub(line))
+	if cwd, err := os.Getwd(); err == nil {
+		prompt += fmt.Sprintf("Working directory: %s\n", builder.Scrub(cwd))
+	}
+	// Session context helps but is optional: the hotkey also works before any command ran.
+	if context, _, err := builder.Build(); err == nil {
+		prompt += "Recent session context:\n" + context
+	}
+
+	svc, err := h.service()
+	if err != nil {
+		h.printError(err)
+		return
+	}
+	out, err := svc.Complete(prompt)
+	if err != nil {
+		h.printError(err)
+		return
+	}
+
+	suggestion := builder.Secrets().Restore(firstLine(out))
+	if suggestion == "" {
+		return
+	}
+	if h.printer.JSON() {
+		h.printer.Result(completeResult{Line: line, Suggestion: suggestion})
+		return
+	}
+	fmt.Println(suggestion)
+}
+
+// firstLine returns the first command-looking line of a response, tolerating code fences and prefixes.
+func firstLine(out string) string {
+	for _, ln := range strings.Split(out, "\n") {
+		ln = strings.TrimSpace(ln)
+		if ln == "" || strings.HasPrefix(ln, "```") {
+			continue
+		}
+		ln = strings.TrimPrefix(ln, "COMMAND: ")
+		ln = strings.TrimPrefix(ln, "$ ")
+		return strings.Trim(ln, "`")
+	}
+	return ""
+}
@@ -0,0 +1,332 @@
+package ai
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	ainternal "github.com/mr-gaber/ai-shell/internal/ai"
+	"github.com/mr-gaber/ai-shell/internal/cli/shared"
+	"github.com/mr-gaber/ai-shell/internal/errs"
+	sessionbuiltins "github.com/mr-gaber/ai-shell/internal/session/builtins"
+	sessioncontext "github.com/mr-gaber/ai-shell/internal/session/context"
+	sessiondanger "github.com/mr-gaber/ai-shell/internal/session/danger"
+	"github.com/mr-gaber/ai-shell/internal/shell"
+	"github.com/mr-gaber/ai-shell/internal/shell/control"
+	"github.com/mr-gaber/ai-shell/internal/shell/runner"
+	"github.com/mr-gaber/ai-shell/internal/ux/clipboard"
+)
+
+// verifyOutputLines caps how much of a failed re-run is sent back to the provider.
+const verifyOutputLines = 60
+
+func (h *Handler) handleFix(rest []string) {
+	fs := flag.NewFlagSet("ai fix", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", h.cfg.Fix.DryRun, "preview the command's file changes in a sandbox before confirming")
+	verify := fs.Bool("verify", h.cfg.Fix.Verify, "re-run the failed command after the fix and iterate while it still fails")
+	maxIterations := fs.Int("max-iterations", h.cfg.Fix.MaxIterations, "maximum fix attempts with --verify")
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(rest); err != nil {
+		h.info("usage: ai fix [--dry-run] [--verify] [--max-iterations N]")
+		return
+	}
+
+	context, builder, err := h.buildContext()
+	if err != nil {
+		h.printError(err)
+		return
+	}
+
+	svc, err := h.service()
+	if err != nil {
+		h.printError(err)
+		return
+	}
+
+	policy, err := sessiondanger.LoadPolicy(h.cfg.Paths.PolicyFile)
+	if err != nil {
+		h.printError(errs.Wrap(err, "danger-policy", "[aish] failed to load danger policy"))
+		return
+	}
+
+	if h.printer.JSON() {
+		h.proposeJSON(svc, policy, context, builder)
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		command, ok := h.proposeFix(svc, context, builder)
+		if !ok {
+			return
+		}
+		persist, choice := h.approve(policy, command, *dryRun)
+		if h.handOff(command, choice) {
+			return
+		}
+
+		if !*verify {
+			h.execute(command, persist)
+			return
+		}
+		if persist {
+			h.execute(command, persist)
+			h.warn("[aish] Shell builtins take effect after ai returns; skipping verification.")
+			return
+		}
+
+		// Verification needs the exit code, so the fix runs here rather than in the live shell.
+		_, fixExit, err := runner.Capture(command, "")
+		if err != nil {
+			h.printError(errs.Wrap(err, "ai-run", "[aish] run error"))
+			return
+		}
+
+		result, ok := h.verifyFix(builder)
+		if !ok {
+			return
+		}
+		if result.exit == 0 {
+			h.success(fmt.Sprintf("[aish] Verified: %q now succeeds.", result.command))
+			return
+		}
+		if attempt >= *maxIterations {
+			h.warn(fmt.Sprintf("[aish] Still failing after %d attempt(s); giving up.", attempt))
+			return
+		}
+
+		context += fmt.Sprintf(`
+Attempt %d: ran %q (exit %d). Re-running the original command still fails with exit %d.
+Output of the re-run (last %d lines):
+%s
+Propose a different fix.
+`, attempt, builder.Scrub(command), fixExit, result.exit, verifyOutputLines, builder.Scrub(tailLines(result.output, verifyOutputLines)))
+	}
+}
+
+// proposeFix asks the provider for a fix and returns the runnable command with masked values restored.
+func (h *Handler) proposeFix(svc *ainternal.Service, context string, builder sessioncontext.Builder) (string, bool) {
+	out, err := svc.Fix(context)
+	if err != nil {
+		h.printError(err)
+		return "", false
+	}
+
+	h.info(out)
+
+	command, masked := parseFix(out, builder)
+	if command == "" {
+		h.warn("[aish] No runnable command was suggested.")
+		return "", false
+	}
+	if len(masked) > 0 {
+		h.info(fmt.Sprintf("[aish] %s will be filled in locally before running.", strings.Join(masked, ", ")))
+	}
+	return command, true
+}
+
+// fixResult is the JSON shape of ai fix. In JSON mode the fix is only proposed, never run.
+type fixResult struct {
+	Explanation string   `json:"explanation"`
+	Command     string   `json:"command"`
+	Masked      []string `json:"masked,omitempty"`
+	Builtin     bool     `json:"builtin"`
+	Severity    string   `json:"severity"`
+	Action      string   `json:"action"`
+	Rule        string   `json:"rule,omitempty"`
+	Reasons     []string `json:"reasons,omitempty"`
+}
+
+// proposeJSON reports the proposed fix and its danger assessment for programmatic callers.
+func (h *Handler) proposeJSON(svc *ainternal.Service, policy *sessiondanger.Policy, context string, builder sessioncontext.Builder) {
+	out, err := svc.Fix(context)
+	if err != nil {
+		h.printError(err)
+		return
+	}
+
+	command, masked := parseFix(out, builder)
+	if command == "" {
+		h.printError(errs.New("ai-no-command", "[aish] No runnable command was suggested.", errs.WithFields(map[string]string{"response": out})))
+		return
+	}
+
+	d := policy.Evaluate(command, sessiondanger.CurrentScope())
+	reasons := make([]string, 0, len(d.Verdict.Reasons))
+	for _, r := range d.Verdict.Reasons {
+		reasons = append(reasons, r.String())
+	}
+	h.printer.Result(fixResult{
+		Explanation: out,
+		Command:     command,
+		Masked:      masked,
+		Builtin:     sessionbuiltins.IsNonPersisting(command),
+		Severity:    d.Verdict.Severity.String(),
+		Action:      string(d.Action),
+		Rule:        d.Rule,
+		Reasons:     reasons,
+	})
+}
+
+// parseFix extracts the command from a provider response and fills in placeholders locally,
+// so masked values never reach the provider. It also returns the placeholders that were filled.
+func parseFix(out string, builder sessioncontext.Builder) (string, []string) {
+	command := strings.TrimPrefix(out, "COMMAND: ")
+	command = strings.TrimSpace(strings.Split(command, "\n")[0])
+	if command == "" {
+		return "", nil
+	}
+
+	secrets := builder.Secrets()
+	masked := secrets.Referenced(command)
+	if len(masked) > 0 {
+		command = secrets.Restore(command)
+	}
+	return command, masked
+}
+
+// approve runs the danger policy, optional dry-run and confirmation prompt for command.
+// It reports whether the command must run in the parent shell and what the user chose.
+func (h *Handler) approve(policy *sessiondanger.Policy, command string, dryRun bool) (bool, shell.Choice) {
+	decision, err := shared.Guard(h.printer, policy, command)
+	if err != nil {
+		h.printError(err)
+		return false, shell.ChoiceNo
+	}
+	persist := sessionbuiltins.IsNonPersisting(command)
+	if persist && h.cfg.Paths.EvalFile == "" && h.cfg.Paths.ControlSock == "" {
+		h.warn("[aish] Note: builtins like 'cd'/'export' won’t change your parent shell; I won’t auto-run them.")
+		return false, shell.ChoiceNo
+	}
+
+	if dryRun {
+		h.dryRun(command)
+	}
+
+	if decision.Action == sessiondanger.ActionConfirm {
+		if shared.ConfirmTyped(h.printer, decision) {
+			return persist, shell.ChoiceYes
+		}
+		return persist, shell.ChoiceNo
+	}
+	h.printer.Prompt("[aish] Run it now? [y/N/e/c] (e: edit in prompt, c: copy) ")
+	return persist, shell.Choose()
+}
+
+// handOff acts on every choice except running the command and reports whether it handled it.
+func (h *Handler) handOff(command string, choice shell.Choice) bool {
+	switch choice {
+	case shell.ChoiceYes:
+		return false
+	case shell.ChoiceEdit:
+		h.edit(command)
+	case shell.ChoiceCopy:
+		if err := clipboard.Copy(command); err != nil {
+			h.printError(errs.Wrap(err, "ai-copy", "[aish] cannot copy to the clipboard"))
+			break
+		}
+		h.success("[aish] Copied to the clipboard.")
+	}
+	return true
+}
+
+// edit places command in the interactive shell's editing buffer so it can be tweaked before running.
+func (h *Handler) edit(command string) {
+	if h.cfg.Paths.ControlSock != "" {
+		err := control.Send(h.cfg.Paths.ControlSock, control.Request{Op: control.OpType, Text: command})
+		if err == nil {
+			return
+		}
+		h.warn(fmt.Sprintf("[aish] cannot reach the interactive shell (%v); using the ai function instead.", err))
+	}
+	if err := runner.SetBuffer(command, h.cfg.Paths.EvalFile); err != nil {
+		h.printError(errs.Wrap(err, "ai-edit", "[aish] cannot place the command in the prompt"))
+	}
+}
+
+// verifyResult is the outcome of re-running the originally failed command.
+type verifyResult struct {
+	command string
+	output  string
+	exit    int
+}
+
+// verifyFix re-runs the last failed command from history, in its original directory, after confirmation.
+func (h *Handler) verifyFix(builder sessioncontext.Builder) (verifyResult, bool) {
+	last, err := builder.LastCommand()
+	if err != nil {
+		h.printError(err)
+		return verifyResult{}, false
+	}
+
+	h.printer.Prompt(fmt.Sprintf("[aish] Re-run %q to verify? [y/N] ", last.Cmd))
+	if !shell.Confirm() {
+		return verifyResult{}, false
+	}
+
+	output, exit, err := runner.Capture(last.Cmd, last.CWD)
+	if err != nil {
+		h.printError(errs.Wrap(err, "ai-verify", "[aish] cannot re-run command", errs.WithFields(map[string]string{"command": last.Cmd})))
+		return verifyResult{}, false
+	}
+	return verifyResult{command: last.Cmd, output: output, exit: exit}, true
+}
+
+// execute runs an approved command, preferring the live interactive shell so aliases,
+// functions and options apply and the command lands in the session history.
+func (h *Handler) execute(command string, persist bool) {
+	if h.cfg.Paths.ControlSock != "" {
+		err := control.Send(h.cfg.Paths.ControlSock, control.Request{Op: control.OpRun, Text: command})
+		if err == nil {
+			return
+		}
+		h.warn(fmt.Sprintf("[aish] cannot reach the interactive shell (%v); running it here instead.", err))
+	}
+
+	if persist {
+		// The ai shell function sources the eval file once this process exits.
+		if err := runner.RunInParent(command, h.cfg.Paths.EvalFile); err != nil {
+			h.printError(errs.Wrap(err, "ai-run", "[aish] run error"))
+		}
+		return
+	}
+	if err := runner.Run(command); err != nil {
+		if h.printer != nil {
+			h.printer.Error(errs.Wrap(err, "ai-run", "[aish] run error"))
+		} else {
+			fmt.Println("[aish] run error:", err)
+		}
+	}
+}
+
+func (h *Handler) dryRun(command string) {
+	h.info("[aish] Dry-run in sandbox (no network, changes discarded):")
+	report, err := runner.DryRun(command)
+	if err != nil {
+		h.printError(errs.Wrap(err, "ai-dry-run", "[aish] dry-run unavailable", errs.WithSeverity(errs.SeverityWarn)))
+		return
+	}
+
+	h.info(fmt.Sprintf("[aish] Dry-run exited with code %d in %s", report.ExitCode, report.Dir))
+	if report.Empty() {
+		h.info("[aish] No files would change.")
+		return
+	}
+	for _, p := range report.Created {
+		h.info("  + " + p)
+	}
+	for _, p := range report.Modified {
+		h.info("  ~ " + p)
+	}
+	for _, p := range report.Deleted {
+		h.info("  - " + p)
+	}
+}
+
+func tailLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,98 @@
+package ai
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	sessioncontext "github.com/mr-gaber/ai-shell/internal/session/context"
+	"github.com/mr-gaber/ai-shell/internal/session/history"
+)
+
+// hintState is persisted in the session dir to debounce background hints.
+type hintState struct {
+	At    time.Time `json:"at"`
+	Entry string    `json:"entry"`
+}
+
+// hintResult is the JSON shape of ai hint.
+type hintResult struct {
+	Hint    string `json:"hint"`
+	Command string `json:"command"`
+	Exit    int    `json:"exit"`
+}
+
+// handleHint is invoked in the background by the prompt hook after a failed command.
+// It stays silent unless hints are enabled and a hint was not shown recently.
+func (h *Handler) handleHint() {
+	h.background = true
+	if !h.cfg.Hints.Enabled || h.cfg.Paths.HistoryFile == "" {
+		return
+	}
+
+	builder := sessioncontext.NewBuilder(h.cfg)
+	last, err := builder.LastCommand()
+	if err != nil || !h.wantsHint(last) || !h.claimHint(last) {
+		return
+	}
+	context, _, err := builder.Build()
+	if err != nil {
+		return
+	}
+
+	svc, err := h.service()
+	if err != nil {
+		return
+	}
+	out, err := svc.Hint(context)
+	if err != nil {
+		return
+	}
+
+	hint := strings.TrimSpace(strings.Split(strings.TrimSpace(out), "\n")[0])
+	if hint == "" {
+		return
+	}
+	if h.printer.JSON() {
+		h.printer.Result(hintResult{Hint: hint, Command: last.Cmd, Exit: last.Exit})
+		return
+	}
+	h.info("\n[aish] hint: " + hint)
+}
+
+// wantsHint filters out successes, interrupts and commands the user opted out of.
+func (h *Handler) wantsHint(e history.Entry) bool {
+	// 130 and 148 are Ctrl-C and Ctrl-Z, not failures worth explaining.
+	if e.Exit == 0 || e.Exit == 130 || e.Exit == 148 {
+		return false
+	}
+	fields := strings.Fields(e.Cmd)
+	if len(fields) == 0 {
+		return false
+	}
+	return !slices.Contains(h.cfg.Hints.Ignore, filepath.Base(fields[0]))
+}
+
+// claimHint records that e is being hinted, refusing when the same entry was already
+// handled or another hint was shown within the debounce window.
+func (h *Handler) claimHint(e history.Entry) bool {
+	path := filepath.Join(filepath.Dir(h.cfg.Paths.HistoryFile), "hint.json")
+	key := e.TS + "\x00" + e.Cmd
+
+	var prev hintState
+	if b, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(b, &prev)
+	}
+	if prev.Entry == key || time.Since(prev.At) < h.cfg.Hints.Debounce {
+		return false
+	}
+
+	b, err := json.Marshal(hintState{At: time.Now(), Entry: key})
+	if err != nil {
+		return false
+	}
+	return os.WriteFile(path, b, 0o600) == nil
+}
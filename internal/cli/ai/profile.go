@@ -0,0 +1,71 @@
+package ai
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mr-gaber/ai-shell/internal/config"
+)
+
+// profileKeys are the settings a profile can change, shown by ai profile.
+var profileKeys = []string{"ai.provider", "ai.model", "ai.temperature", "ai.prompt", "paths.policy"}
+
+// profileSetting is the JSON shape of one effective setting in ai profile.
+type profileSetting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Origin string `json:"origin"`
+}
+
+// profileResult is the JSON shape of ai profile.
+type profileResult struct {
+	Directory string           `json:"directory"`
+	Profile   string           `json:"profile,omitempty"`
+	MatchedBy string           `json:"matched_by,omitempty"`
+	Pattern   string           `json:"pattern,omitempty"`
+	Subject   string           `json:"subject,omitempty"`
+	Settings  []profileSetting `json:"settings"`
+	Defined   []string         `json:"defined"`
+}
+
+// handleProfile shows which profile applies to the working directory and the AI settings in effect.
+func (h *Handler) handleProfile() {
+	dir, _ := os.Getwd()
+	settings, _ := config.Resolve(dir)
+
+	res := profileResult{Directory: dir, Defined: []string{}}
+	for _, p := range settings.Profiles() {
+		res.Defined = append(res.Defined, p.Name)
+	}
+	m, active := settings.ActiveProfile()
+	if active {
+		res.Profile, res.MatchedBy, res.Pattern, res.Subject = m.Profile.Name, m.By, m.Pattern, m.Subject
+	}
+	for _, name := range profileKeys {
+		if s, ok := settings.Get(name); ok {
+			res.Settings = append(res.Settings, profileSetting{Key: name, Value: s.Value(), Origin: s.Origin.String()})
+		}
+	}
+
+	if h.printer.JSON() {
+		h.printer.Result(res)
+		return
+	}
+
+	switch {
+	case active:
+		h.info(fmt.Sprintf("[aish] profile %s: %s %s matches %s", m.Profile.Name, m.By, m.Pattern, m.Subject))
+	case len(res.Defined) == 0:
+		h.info("[aish] no profiles defined; add them under profiles: in " + config.UserFile())
+	default:
+		h.info(fmt.Sprintf("[aish] no profile matches %s (defined: %s)", dir, strings.Join(res.Defined, ", ")))
+	}
+	for _, s := range res.Settings {
+		value := s.Value
+		if value == "" {
+			value = "(none)"
+		}
+		h.info(fmt.Sprintf("  %s = %s    (%s)", s.Key, value, s.Origin))
+	}
+}
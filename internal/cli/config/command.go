@@ -0,0 +1,170 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mr-gaber/ai-shell/internal/cli/shared"
+	appconfig "github.com/mr-gaber/ai-shell/internal/config"
+	"github.com/mr-gaber/ai-shell/internal/errs"
+	"github.com/mr-gaber/ai-shell/internal/ux/printer"
+)
+
+// Handler processes the config command parsed by the router.
+type Handler struct {
+	printer *printer.Printer
+}
+
+func New(p *printer.Printer) *Handler {
+	return &Handler{printer: p}
+}
+
+func (h *Handler) Handle(args []string) {
+	if len(args) == 0 || args[0] == "help" {
+		h.usage()
+		return
+	}
+
+	switch args[0] {
+	case "show":
+		h.handleShow(args[1:])
+	case "get":
+		h.handleGet(args[1:])
+	case "set":
+		h.handleSet(args[1:])
+	default:
+		h.printer.Error(errs.New("config-unknown-subcommand", fmt.Sprintf("config: unknown subcommand %q", args[0])))
+	}
+}
+
+func (h *Handler) usage() {
+	shared.PrintUsage(h.printer, `config usage:
+  aish config show [--origin]                   // Print every effective setting
+  aish config get [--origin] <key>              // Print one setting
+  aish config set [--project] <key> <value>     // Write ~/.aish/config.yaml (or the project's .aish.yaml)`)
+}
+
+// setting is the JSON shape of one resolved key.
+type setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Origin string `json:"origin"`
+	Env    string `json:"env"`
+}
+
+func (h *Handler) handleShow(rest []string) {
+	fs := flag.NewFlagSet("config show", flag.ContinueOnError)
+	origin := fs.Bool("origin", false, "show where each value came from")
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(rest); err != nil || fs.NArg() != 0 {
+		h.usage()
+		return
+	}
+
+	settings := h.resolve()
+	if h.printer.JSON() {
+		out := make([]setting, 0, len(settings.All()))
+		for _, s := range settings.All() {
+			out = append(out, toSetting(s))
+		}
+		h.printer.Result(struct {
+			Settings []setting `json:"settings"`
+		}{out})
+		return
+	}
+
+	for _, s := range settings.All() {
+		h.printer.Info(formatSetting(s, *origin))
+	}
+}
+
+func (h *Handler) handleGet(rest []string) {
+	fs := flag.NewFlagSet("config get", flag.ContinueOnError)
+	origin := fs.Bool("origin", false, "show where the value came from")
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(rest); err != nil || fs.NArg() != 1 {
+		h.usage()
+		return
+	}
+
+	s, ok := h.resolve().Get(fs.Arg(0))
+	if !ok {
+		h.printer.Error(errs.New("config-unknown-key", fmt.Sprintf("[aish] unknown config key %q", fs.Arg(0)), errs.WithFields(map[string]string{"key": fs.Arg(0)})))
+		return
+	}
+	if h.printer.JSON() {
+		h.printer.Result(toSetting(s))
+		return
+	}
+	if *origin {
+		h.printer.Info(formatSetting(s, true))
+		return
+	}
+	h.printer.Info(s.Value())
+}
+
+func (h *Handler) handleSet(rest []string) {
+	fs := flag.NewFlagSet("config set", flag.ContinueOnError)
+	project := fs.Bool("project", false, "write the project's .aish.yaml instead of ~/.aish/config.yaml")
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(rest); err != nil || fs.NArg() < 2 {
+		h.usage()
+		return
+	}
+
+	key, value := fs.Arg(0), strings.Join(fs.Args()[1:], " ")
+	path, source := appconfig.UserFile(), appconfig.SourceUser
+	if *project {
+		source = appconfig.SourceProject
+		path = appconfig.FindProjectFile(cwd())
+		if path == "" {
+			path = filepath.Join(cwd(), appconfig.ProjectFileName)
+		}
+	}
+
+	if err := appconfig.Set(path, source, key, value); err != nil {
+		h.printer.Error(err)
+		return
+	}
+
+	// An env override still wins over the file, which is easy to miss after a set.
+	s, _ := h.resolve().Get(key)
+	if h.printer.JSON() {
+		h.printer.Result(struct {
+			File string `json:"file"`
+			setting
+		}{path, toSetting(s)})
+		return
+	}
+	h.printer.Success(fmt.Sprintf("[aish] %s = %s written to %s", key, value, path))
+	if s.Origin.Source == appconfig.SourceEnv {
+		h.printer.Warn(fmt.Sprintf("[aish] $%s is set and overrides this value.", s.Key.Env))
+	}
+}
+
+// resolve re-reads the layers; problems were already reported when the app started.
+func (h *Handler) resolve() *appconfig.Settings {
+	settings, _ := appconfig.Resolve(cwd())
+	return settings
+}
+
+func toSetting(s appconfig.Setting) setting {
+	return setting{Key: s.Key.Name, Value: s.Value(), Origin: s.Origin.String(), Env: s.Key.Env}
+}
+
+func formatSetting(s appconfig.Setting, origin bool) string {
+	line := fmt.Sprintf("%s = %s", s.Key.Name, s.Value())
+	if origin {
+		line += fmt.Sprintf("    (%s)", s.Origin)
+	}
+	return line
+}
+
+func cwd() string {
+	dir, _ := os.Getwd()
+	return dir
+}
@@ -0,0 +1,255 @@
+package key
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mr-gaber/ai-shell/internal/cli/shared"
+	"github.com/mr-gaber/ai-shell/internal/config"
+	"github.com/mr-gaber/ai-shell/internal/credentials"
+	"github.com/mr-gaber/ai-shell/internal/errs"
+	"github.com/mr-gaber/ai-shell/internal/ux/printer"
+	"golang.org/x/term"
+)
+
+// Handler processes the key command parsed by the router.
+type Handler struct {
+	cfg     config.Config
+	printer *printer.Printer
+}
+
+func New(cfg config.Config, p *printer.Printer) *Handler {
+	return &Handler{cfg: cfg, printer: p}
+}
+
+func (h *Handler) Handle(args []string) {
+	if len(args) == 0 || args[0] == "help" {
+		shared.PrintUsage(h.printer, `key usage:
+  aish key set [name]       // Store an API key in the encrypted credentials file (default: openai)
+  aish key delete [name]    // Remove a stored key
+  aish key status           // Show where each key is available from
+  aish key lock             // Forget keys unlocked in the kernel keyring`)
+		return
+	}
+
+	switch args[0] {
+	case "set":
+		h.handleSet(args[1:])
+	case "delete":
+		h.handleDelete(args[1:])
+	case "status":
+		h.handleStatus()
+	case "lock":
+		h.handleLock()
+	default:
+		h.printer.Error(errs.New("key-unknown-subcommand", fmt.Sprintf("key: unknown subcommand %q", args[0])))
+	}
+}
+
+// keyStatus is the JSON shape of one credential in key status.
+type keyStatus struct {
+	Key      string `json:"key"`
+	Stored   bool   `json:"stored"`
+	Unlocked bool   `json:"unlocked"`
+	Env      bool   `json:"env"`
+}
+
+func (h *Handler) handleSet(rest []string) {
+	k, ok := h.resolveKey(rest)
+	if !ok {
+		return
+	}
+
+	value, err := readValue(fmt.Sprintf("[aish] %s: ", k.Doc))
+	if err != nil {
+		h.printer.Error(err)
+		return
+	}
+	if value == "" {
+		h.printer.Error(errs.New("key-empty", "[aish] empty value; nothing stored", errs.WithFields(map[string]string{"key": k.Name})))
+		return
+	}
+
+	store := credentials.NewStore(h.cfg.Paths.CredentialsFile)
+	passphrase, values, err := h.unlock(store, true)
+	if err != nil {
+		h.printer.Error(err)
+		return
+	}
+	values[k.Name] = value
+	if err := store.Save(passphrase, values); err != nil {
+		h.printer.Error(err)
+		return
+	}
+	credentials.Remember(k.Name, value, h.cfg.Credentials.CacheTTL)
+
+	if h.printer.JSON() {
+		h.printer.Result(h.status(k))
+		return
+	}
+	h.printer.Success(fmt.Sprintf("[aish] %s stored in %s", k.Name, store.Path()))
+	if os.Getenv(k.Env) != "" {
+		h.printer.Warn(fmt.Sprintf("[aish] $%s is still set; unset it so the key stays out of your environment.", k.Env))
+	}
+}
+
+func (h *Handler) handleDelete(rest []string) {
+	k, ok := h.resolveKey(rest)
+	if !ok {
+		return
+	}
+
+	store := credentials.NewStore(h.cfg.Paths.CredentialsFile)
+	if !store.Contains(k.Name) {
+		h.printer.Error(errs.New("key-not-stored", fmt.Sprintf("[aish] %s is not stored in %s", k.Name, store.Path()), errs.WithFields(map[string]string{"key": k.Name})))
+		return
+	}
+	passphrase, values, err := h.unlock(store, false)
+	if err != nil {
+		h.printer.Error(err)
+		return
+	}
+	delete(values, k.Name)
+	if err := store.Save(passphrase, values); err != nil {
+		h.printer.Error(err)
+		return
+	}
+	credentials.Lock(k.Name)
+
+	if h.printer.JSON() {
+		h.printer.Result(h.status(k))
+		return
+	}
+	h.printer.Success(fmt.Sprintf("[aish] %s removed from %s", k.Name, store.Path()))
+}
+
+func (h *Handler) handleStatus() {
+	keys := config.SecretKeys()
+	if h.printer.JSON() {
+		out := make([]keyStatus, 0, len(keys))
+		for _, k := range keys {
+			out = append(out, h.status(k))
+		}
+		h.printer.Result(struct {
+			File string      `json:"file"`
+			Keys []keyStatus `json:"keys"`
+		}{h.cfg.Paths.CredentialsFile, out})
+		return
+	}
+
+	h.printer.Info("[aish] credentials file: " + h.cfg.Paths.CredentialsFile)
+	for _, k := range keys {
+		s := h.status(k)
+		var where []string
+		if s.Stored {
+			where = append(where, "stored")
+		}
+		if s.Unlocked {
+			where = append(where, "unlocked")
+		}
+		if s.Env {
+			where = append(where, "$"+k.Env)
+		}
+		if len(where) == 0 {
+			where = append(where, "not set")
+		}
+		h.printer.Info(fmt.Sprintf("  %s: %s", k.Name, strings.Join(where, ", ")))
+	}
+}
+
+func (h *Handler) handleLock() {
+	for _, k := range config.SecretKeys() {
+		credentials.Lock(k.Name)
+	}
+	if h.printer.JSON() {
+		h.printer.Result(struct {
+			Locked bool `json:"locked"`
+		}{true})
+		return
+	}
+	h.printer.Success("[aish] Cached keys forgotten; the next ai command will ask for the passphrase.")
+}
+
+func (h *Handler) status(k config.Key) keyStatus {
+	return keyStatus{
+		Key:      k.Name,
+		Stored:   credentials.NewStore(h.cfg.Paths.CredentialsFile).Contains(k.Name),
+		Unlocked: credentials.Cached(k.Name),
+		Env:      os.Getenv(k.Env) != "",
+	}
+}
+
+// resolveKey maps the optional name argument to a secret config key; a bare provider name
+// such as "openai" means its API key.
+func (h *Handler) resolveKey(rest []string) (config.Key, bool) {
+	if len(rest) > 1 {
+		shared.PrintUsage(h.printer, "usage: aish key set|delete [name]")
+		return config.Key{}, false
+	}
+	name := "openai"
+	if len(rest) == 1 {
+		name = rest[0]
+	}
+	if !strings.Contains(name, ".") {
+		name += ".api_key"
+	}
+
+	k, ok := config.Lookup(name)
+	if !ok || !k.Secret {
+		var known []string
+		for _, s := range config.SecretKeys() {
+			known = append(known, s.Name)
+		}
+		h.printer.Error(errs.New("key-unknown", fmt.Sprintf("[aish] %q is not a credential; known: %s", name, strings.Join(known, ", ")), errs.WithFields(map[string]string{"key": name})))
+		return config.Key{}, false
+	}
+	return k, true
+}
+
+// unlock asks for the passphrase and decrypts the store. When the file does not exist yet and
+// create is set, a new passphrase is asked for twice instead.
+func (h *Handler) unlock(store *credentials.Store, create bool) (string, map[string]string, error) {
+	if !store.Exists() && create {
+		passphrase, err := credentials.ReadPassphrase("[aish] New passphrase for " + store.Path() + ": ")
+		if err != nil {
+			return "", nil, err
+		}
+		if passphrase == "" {
+			return "", nil, errs.New("key-passphrase", "[aish] the passphrase cannot be empty")
+		}
+		again, err := credentials.ReadPassphrase("[aish] Repeat passphrase: ")
+		if err != nil {
+			return "", nil, err
+		}
+		if again != passphrase {
+			return "", nil, errs.New("key-passphrase", "[aish] passphrases do not match")
+		}
+		return passphrase, map[string]string{}, nil
+	}
+
+	passphrase, err := credentials.ReadPassphrase("[aish] Passphrase for " + store.Path() + ": ")
+	if err != nil {
+		return "", nil, err
+	}
+	values, err := store.Load(passphrase)
+	if err != nil {
+		return "", nil, err
+	}
+	return passphrase, values, nil
+}
+
+// readValue reads the secret without echo, or the first line of stdin when it is piped so
+// keys can be stored from a password manager.
+func readValue(prompt string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", errs.Wrap(err, "key-read", "[aish] cannot read the key from stdin")
+		}
+		return strings.TrimSpace(line), nil
+	}
+	value, err := credentials.ReadPassphrase(prompt)
+	return strings.TrimSpace(value), err
+}
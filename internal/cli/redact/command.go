@@ -0,0 +1,131 @@
+package redact
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mr-gaber/ai-shell/internal/cli/shared"
+	"github.com/mr-gaber/ai-shell/internal/config"
+	"github.com/mr-gaber/ai-shell/internal/errs"
+	sessionredact "github.com/mr-gaber/ai-shell/internal/session/redact"
+	"github.com/mr-gaber/ai-shell/internal/ux/printer"
+)
+
+// Handler processes the redact command parsed by the router.
+type Handler struct {
+	cfg     config.Config
+	printer *printer.Printer
+}
+
+func New(cfg config.Config, p *printer.Printer) *Handler {
+	return &Handler{cfg: cfg, printer: p}
+}
+
+func (h *Handler) Handle(args []string) {
+	if len(args) > 0 && args[0] == "help" {
+		h.usage()
+		return
+	}
+
+	fs := flag.NewFlagSet("redact", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "list what would be masked instead of printing the scrubbed text")
+	rulesPath := fs.String("rules", "", "redaction rules file")
+	fs.SetOutput(io.Discard)
+
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		h.usage()
+		return
+	}
+
+	path := *rulesPath
+	if path == "" {
+		path = h.defaultRulesPath()
+	}
+
+	redactor, err := sessionredact.Load(path)
+	if err != nil {
+		h.printer.Error(errs.Wrap(err, "redact-rules", "failed to load redaction rules", errs.WithFields(map[string]string{"file": path})))
+		return
+	}
+
+	target := fs.Arg(0)
+	text, err := readInput(target)
+	if err != nil {
+		h.printer.Error(errs.Wrap(err, "redact-read", "failed to read input", errs.WithFields(map[string]string{"file": target})))
+		return
+	}
+
+	if !*dryRun {
+		if h.printer.JSON() {
+			h.printer.Result(scrubResult{Text: redactor.Scrub(text)})
+			return
+		}
+		fmt.Print(redactor.Scrub(text))
+		return
+	}
+
+	findings := redactor.Findings(text)
+	if h.printer.JSON() {
+		out := make([]finding, 0, len(findings))
+		for _, f := range findings {
+			out = append(out, finding{Rule: f.Rule, Line: f.Line, Value: f.Value})
+		}
+		h.printer.Result(findingsResult{Findings: out})
+		return
+	}
+	if len(findings) == 0 {
+		h.printer.Success("[aish] nothing would be masked")
+		return
+	}
+	for _, f := range findings {
+		h.printer.Info(fmt.Sprintf("line %d [%s] %s", f.Line, f.Rule, f.Value))
+	}
+	h.printer.Warn(fmt.Sprintf("[aish] %d value(s) would be masked", len(findings)))
+}
+
+// scrubResult is the JSON shape of redact.
+type scrubResult struct {
+	Text string `json:"text"`
+}
+
+// findingsResult is the JSON shape of redact --dry-run.
+type findingsResult struct {
+	Findings []finding `json:"findings"`
+}
+
+type finding struct {
+	Rule  string `json:"rule"`
+	Line  int    `json:"line"`
+	Value string `json:"value"`
+}
+
+func (h *Handler) usage() {
+	shared.PrintUsage(h.printer, `redact usage:
+  aish redact [--rules <file>] <file|->           // Print the file with secrets masked
+  aish redact --dry-run [--rules <file>] <file|-> // Show what would be masked
+  aish redact --output json [...]                 // Print the result as JSON`)
+}
+
+func (h *Handler) defaultRulesPath() string {
+	if h.cfg.Paths.RedactFile != "" {
+		return h.cfg.Paths.RedactFile
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".aish", "redact.yaml")
+}
+
+func readInput(target string) (string, error) {
+	if target == "-" {
+		b, err := io.ReadAll(os.Stdin)
+		return string(b), err
+	}
+	b, err := os.ReadFile(strings.TrimSpace(target))
+	return string(b), err
+}
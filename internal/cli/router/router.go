@@ -1,27 +1,51 @@
 package router
 
 import (
+	"fmt"
+	"os"
+	"strings"
+
 	"github.com/mr-gaber/ai-shell/internal/cli/ai"
+	cliconfig "github.com/mr-gaber/ai-shell/internal/cli/config"
+	clikey "github.com/mr-gaber/ai-shell/internal/cli/key"
+	cliredact "github.com/mr-gaber/ai-shell/internal/cli/redact"
 	clipsnip "github.com/mr-gaber/ai-shell/internal/cli/snip"
 	"github.com/mr-gaber/ai-shell/internal/config"
+	"github.com/mr-gaber/ai-shell/internal/shell/runner"
 	"github.com/mr-gaber/ai-shell/internal/ux/printer"
 )
 
 // Router dispatches internal commands to their handlers based on argv.
 type Router struct {
-	ai   *ai.Handler
-	snip *clipsnip.Handler
+	ai      *ai.Handler
+	snip    *clipsnip.Handler
+	redact  *cliredact.Handler
+	config  *cliconfig.Handler
+	key     *clikey.Handler
+	printer *printer.Printer
 }
 
 func New(cfg config.Config, p *printer.Printer) *Router {
 	return &Router{
-		ai:   ai.New(cfg, p),
-		snip: clipsnip.New(cfg, p),
+		ai:      ai.New(cfg, p),
+		snip:    clipsnip.New(cfg, p),
+		redact:  cliredact.New(cfg, p),
+		config:  cliconfig.New(p),
+		key:     clikey.New(cfg, p),
+		printer: p,
 	}
 }
 
 func (r *Router) Route(args []string) bool {
 	if len(args) > 1 {
+		if args[1] != "__sandbox" {
+			rest, ok := r.applyOutputFlag(args[2:])
+			if !ok {
+				return true
+			}
+			args = append(args[:2:2], rest...)
+		}
+
 		switch args[1] {
 		case "__ai":
 			r.ai.Handle(args[2:])
@@ -29,7 +53,50 @@ func (r *Router) Route(args []string) bool {
 		case "__snip":
 			r.snip.Handle(args[2:])
 			return true
+		case "__sandbox":
+			os.Exit(runner.SandboxChild(args[2:]))
+		case "redact":
+			r.redact.Handle(args[2:])
+			return true
+		case "config":
+			r.config.Handle(args[2:])
+			return true
+		case "key":
+			r.key.Handle(args[2:])
+			return true
 		}
 	}
 	return false
 }
+
+// applyOutputFlag consumes --output <format> (or --output=<format>) when it is the first
+// argument or directly follows the subcommand, so payload text such as snippet commands is
+// never mistaken for it. It reports false when the format is invalid.
+func (r *Router) applyOutputFlag(args []string) ([]string, bool) {
+	for i := 0; i < len(args) && i < 2; i++ {
+		value, n := "", 0
+		switch {
+		case args[i] == "--output" && i+1 < len(args):
+			value, n = args[i+1], 2
+		case strings.HasPrefix(args[i], "--output="):
+			value, n = strings.TrimPrefix(args[i], "--output="), 1
+		default:
+			continue
+		}
+
+		format, err := printer.ParseFormat(value)
+		if err != nil {
+			if r.printer != nil {
+				r.printer.Error(err)
+			} else {
+				fmt.Println(err)
+			}
+			return nil, false
+		}
+		if r.printer != nil {
+			r.printer.SetFormat(format)
+		}
+		return append(args[:i:i], args[i+n:]...), true
+	}
+	return args, true
+}
@@ -0,0 +1,25 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+
+	ainternal "github.com/mr-gaber/ai-shell/internal/ai"
+	"github.com/mr-gaber/ai-shell/internal/config"
+	"github.com/mr-gaber/ai-shell/internal/credentials"
+	"github.com/mr-gaber/ai-shell/internal/errs"
+)
+
+// AIService fills in the provider's stored API key, prompting for the passphrase when
+// interactive is set, and starts the AI service. When no key is configured the error has
+// code ai-not-configured and tells the user how to set one up to use command.
+func AIService(cfg *config.Config, interactive bool, command string) (*ainternal.Service, error) {
+	if err := credentials.Fill(cfg, interactive); err != nil {
+		return nil, err
+	}
+	svc, err := ainternal.NewService(*cfg)
+	if errors.Is(err, ainternal.ErrNotConfigured) {
+		return nil, errs.Wrap(err, "ai-not-configured", fmt.Sprintf("[aish] No AI configured. Run 'aish key set openai' or set OPENAI_API_KEY to use %s.", command))
+	}
+	return svc, err
+}
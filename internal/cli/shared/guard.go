@@ -0,0 +1,61 @@
+package shared
+
+import (
+	"fmt"
+
+	"github.com/mr-gaber/ai-shell/internal/errs"
+	"github.com/mr-gaber/ai-shell/internal/session/danger"
+	"github.com/mr-gaber/ai-shell/internal/shell"
+	"github.com/mr-gaber/ai-shell/internal/ux/printer"
+)
+
+// Guard evaluates command against the built-in analyser and the user's policy, printing
+// any findings. It returns an error when the command must not run.
+func Guard(p *printer.Printer, policy *danger.Policy, command string) (danger.Decision, error) {
+	d := policy.Evaluate(command, danger.CurrentScope())
+
+	for _, reason := range d.Verdict.Reasons {
+		warn(p, "  "+reason.String())
+	}
+	if d.Message != "" {
+		warn(p, fmt.Sprintf("[aish] policy %q: %s", d.Rule, d.Message))
+	}
+
+	if d.Action == danger.ActionBlock {
+		fields := map[string]string{"command": command}
+		if d.Rule != "" {
+			fields["rule"] = d.Rule
+		}
+		return d, errs.New("danger-blocked", "[aish] This command looks dangerous; refusing to run it.", errs.WithFields(fields))
+	}
+	return d, nil
+}
+
+// ConfirmTyped asks the user to type the rule name back before a command guarded by a confirm rule runs.
+func ConfirmTyped(p *printer.Printer, d danger.Decision) bool {
+	warn(p, fmt.Sprintf("[aish] policy %q requires typed confirmation.", d.Rule))
+	p.Prompt(fmt.Sprintf("[aish] Type %q to run it: ", d.Rule))
+	return shell.ConfirmTyped(d.Rule)
+}
+
+// GuardFunc adapts Guard and ConfirmTyped into a single check suitable for non-interactive callers.
+func GuardFunc(p *printer.Printer, policy *danger.Policy) func(command string) error {
+	return func(command string) error {
+		d, err := Guard(p, policy, command)
+		if err != nil {
+			return err
+		}
+		if d.Action == danger.ActionConfirm && !ConfirmTyped(p, d) {
+			return errs.New("danger-declined", "[aish] confirmation did not match; not running", errs.WithFields(map[string]string{"command": command}))
+		}
+		return nil
+	}
+}
+
+func warn(p *printer.Printer, msg string) {
+	if p != nil {
+		p.Warn(msg)
+		return
+	}
+	fmt.Println(msg)
+}
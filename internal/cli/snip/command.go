@@ -2,14 +2,20 @@ package snip
 
 import (
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/mr-gaber/ai-shell/internal/cli/shared"
 	"github.com/mr-gaber/ai-shell/internal/config"
 	"github.com/mr-gaber/ai-shell/internal/errs"
+	"github.com/mr-gaber/ai-shell/internal/session/danger"
 	"github.com/mr-gaber/ai-shell/internal/shell"
+	"github.com/mr-gaber/ai-shell/internal/shell/control"
+	"github.com/mr-gaber/ai-shell/internal/snippets/model"
 	"github.com/mr-gaber/ai-shell/internal/snippets/service"
 	"github.com/mr-gaber/ai-shell/internal/ux/printer"
+	"golang.org/x/term"
 )
 
 // Handler processes snippet subcommands parsed by the router.
@@ -25,7 +31,7 @@ func New(cfg config.Config, p *printer.Printer) *Handler {
 
 func (h *Handler) Handle(args []string) {
 	if len(args) == 0 || args[0] == "help" {
-		shared.PrintUsage(h.printer, "snip usage:\n  snip ls\n  snip add <name> <command...>\n  snip run <name>\n  snip view <name>\n  snip delete <name>")
+		shared.PrintUsage(h.printer, "snip usage:\n  snip ls\n  snip add <name> <command...>\n  snip run [--inject] <name> [var=value ...]\n  snip view <name>\n  snip param <name> <var> [--type t] [--default v | --required] [--desc text]\n  snip step <name> <n> [--when cond] [--retries N] [--backoff d] [--timeout d] [--continue-on-error]\n  snip record <name>\n  snip stop [--discard]\n  snip save <name> [--last N | --seq id|from-to]\n  snip save --list [N]\n  snip gen <name> \"<description>\"\n  snip delete <name>\n  snip --output json <subcommand> ...")
 		return
 	}
 
@@ -40,11 +46,30 @@ func (h *Handler) Handle(args []string) {
 		h.handleView(args[1:])
 	case "delete":
 		h.handleDelete(args[1:])
+	case "param":
+		h.handleParam(args[1:])
+	case "step":
+		h.handleStep(args[1:])
+	case "record":
+		h.handleRecord(args[1:])
+	case "stop":
+		h.handleStop(args[1:])
+	case "save":
+		h.handleSave(args[1:])
+	case "gen":
+		h.handleGen(args[1:])
 	default:
-		h.warn(fmt.Sprintf("snip: unknown subcommand %q", args[0]))
+		h.error(errs.New("snip-unknown-subcommand", fmt.Sprintf("snip: unknown subcommand %q", args[0])))
 	}
 }
 
+// addResult is the JSON shape of snip add.
+type addResult struct {
+	Name     string   `json:"name"`
+	Created  bool     `json:"created"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
 func (h *Handler) handleAdd(rest []string) {
 	if len(rest) < 2 {
 		h.info("usage: snip add <name> <command...>")
@@ -66,6 +91,11 @@ func (h *Handler) handleAdd(rest []string) {
 		return
 	}
 
+	if h.printer.JSON() {
+		h.printer.Result(addResult{Name: name, Created: created, Warnings: warnings})
+		return
+	}
+
 	for _, warning := range warnings {
 		h.warn("War: " + warning)
 	}
@@ -78,15 +108,39 @@ func (h *Handler) handleAdd(rest []string) {
 	h.success("Snippet created successfully!")
 }
 
+// runResult is the JSON shape of snip run. Step output goes to stderr in JSON mode. Ran is
+// false when the user declined to run the snippet.
+type runResult struct {
+	Name     string               `json:"name"`
+	Ran      bool                 `json:"ran"`
+	Injected bool                 `json:"injected"`
+	Script   string               `json:"script,omitempty"`
+	Steps    []service.StepResult `json:"steps,omitempty"`
+}
+
 func (h *Handler) handleRun(rest []string) {
+	inject := false
+	args := make([]string, 0, len(rest))
+	for _, a := range rest {
+		if a == "--inject" {
+			inject = true
+			continue
+		}
+		args = append(args, a)
+	}
+	rest = args
+
 	if len(rest) < 1 {
-		h.info("usage: snip run <name>")
+		h.info("usage: snip run [--inject] <name> [var=value ...]")
 		return
 	}
 
-	fmt.Print("[aish] Run it now? [y/N] ")
-	yes := shell.ConfirmFromStdin()
+	h.printer.Prompt("[aish] Run it now? [y/N] ")
+	yes := shell.Confirm()
 	if !yes {
+		if h.printer.JSON() {
+			h.printer.Result(runResult{Name: rest[0]})
+		}
 		return
 	}
 
@@ -98,11 +152,48 @@ func (h *Handler) handleRun(rest []string) {
 
 	vars := rest[1:]
 
-	if err := svc.Run(rest[0], vars); err != nil {
+	if inject {
+		h.inject(svc, rest[0], vars)
+		return
+	}
+
+	results, err := svc.Run(rest[0], vars)
+	if h.printer.JSON() {
+		res := runResult{Name: rest[0], Ran: true, Steps: results}
+		if err != nil {
+			h.printer.Failure(res, err)
+			return
+		}
+		h.printer.Result(res)
+		return
+	}
+	h.summary(results)
+	h.error(err)
+}
+
+// inject types the rendered snippet into the interactive shell so it runs with the
+// session's aliases, functions and options and is recorded in its history.
+func (h *Handler) inject(svc *service.Service, name string, vars []string) {
+	script, err := svc.Script(name, vars)
+	if err != nil {
 		h.error(err)
+		return
+	}
+	if err := control.Send(h.cfg.Paths.ControlSock, control.Request{Op: control.OpRun, Text: script}); err != nil {
+		h.error(errs.Wrap(err, "snip-inject", "[aish] cannot send snippet to the interactive shell"))
+		return
+	}
+	if h.printer.JSON() {
+		h.printer.Result(runResult{Name: name, Ran: true, Injected: true, Script: script})
 	}
 }
 
+// viewResult is the JSON shape of snip view.
+type viewResult struct {
+	Name string `json:"name"`
+	model.Snippet
+}
+
 func (h *Handler) handleView(rest []string) {
 	if len(rest) < 1 {
 		h.info("usage: snip view <name>")
@@ -115,9 +206,46 @@ func (h *Handler) handleView(rest []string) {
 		return
 	}
 
-	if err := svc.View(rest[0]); err != nil {
+	snip, err := svc.View(rest[0])
+	if err != nil {
 		h.error(err)
+		return
+	}
+	if h.printer.JSON() {
+		h.printer.Result(viewResult{Name: rest[0], Snippet: snip})
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "snippet: %s\n", rest[0])
+	if snip.CreatedAt != nil {
+		fmt.Fprintf(&b, "created: %s\n", snip.CreatedAt.Format(time.RFC3339))
+	}
+	if params := snip.ParamList(); len(params) > 0 {
+		b.WriteString("vars:\n")
+		for _, p := range params {
+			fmt.Fprintf(&b, "  %s\n", describeParam(p))
+		}
 	}
+	if len(snip.Steps) > 0 {
+		b.WriteString("steps:\n")
+		for i, step := range snip.Steps {
+			if len(step.Cmd) > 0 {
+				fmt.Fprintf(&b, "[%d] $ %s\n", i+1, step.Cmd)
+			} else {
+				fmt.Fprintf(&b, "[%d] $ %s\n", i+1, strings.Join(step.Exec, " "))
+			}
+			if step.HasFlow() {
+				fmt.Fprintf(&b, "    %s\n", describeFlow(step))
+			}
+		}
+	}
+	h.info(b.String())
+}
+
+// listResult is the JSON shape of snip ls.
+type listResult struct {
+	Snippets []string `json:"snippets"`
 }
 
 func (h *Handler) handleList() {
@@ -127,11 +255,27 @@ func (h *Handler) handleList() {
 		return
 	}
 
-	if err := svc.List(); err != nil {
+	names, err := svc.List()
+	if err != nil {
 		h.error(err)
+		return
+	}
+	if h.printer.JSON() {
+		h.printer.Result(listResult{Snippets: names})
+		return
+	}
+
+	for i, name := range names {
+		h.info(fmt.Sprintf("[%d] %s", i, name))
 	}
 }
 
+// deleteResult is the JSON shape of snip delete.
+type deleteResult struct {
+	Name    string `json:"name"`
+	Deleted bool   `json:"deleted"`
+}
+
 func (h *Handler) handleDelete(rest []string) {
 	if len(rest) < 1 {
 		h.info("usage: snip delete <name>")
@@ -149,6 +293,10 @@ func (h *Handler) handleDelete(rest []string) {
 		return
 	}
 
+	if h.printer.JSON() {
+		h.printer.Result(deleteResult{Name: rest[0], Deleted: true})
+		return
+	}
 	h.success(fmt.Sprintf("[aish]: Snippet: %s deleted successfully!", rest[0]))
 }
 
@@ -157,7 +305,19 @@ func (h *Handler) ensureService() (*service.Service, error) {
 		return h.svc, nil
 	}
 
-	svc, err := service.New(h.cfg.Paths.SnippetsFile)
+	policy, err := danger.LoadPolicy(h.cfg.Paths.PolicyFile)
+	if err != nil {
+		return nil, errs.Wrap(err, "danger-policy", "failed to load danger policy")
+	}
+
+	opts := []service.Option{service.WithGuard(shared.GuardFunc(h.printer, policy))}
+	if h.printer.JSON() {
+		opts = append(opts, service.WithOutput(os.Stderr))
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		opts = append(opts, service.WithPrompter(h.promptParam))
+	}
+	svc, err := service.New(h.cfg.Paths.SnippetsFile, opts...)
 	if err != nil {
 		return nil, errs.Wrap(err, "snip-init", "failed to prepare snippets storage")
 	}
@@ -0,0 +1,292 @@
+package snip
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	ainternal "github.com/mr-gaber/ai-shell/internal/ai"
+	"github.com/mr-gaber/ai-shell/internal/cli/shared"
+	"github.com/mr-gaber/ai-shell/internal/errs"
+	"github.com/mr-gaber/ai-shell/internal/session/danger"
+	"github.com/mr-gaber/ai-shell/internal/shell"
+	"github.com/mr-gaber/ai-shell/internal/snippets/model"
+	"github.com/mr-gaber/ai-shell/internal/snippets/parser"
+	"github.com/mr-gaber/ai-shell/internal/snippets/template"
+	"github.com/mr-gaber/ai-shell/internal/utils"
+)
+
+// genCheck is the danger assessment of one generated step.
+type genCheck struct {
+	Step     int      `json:"step"`
+	Command  string   `json:"command"`
+	Checked  string   `json:"checked"`
+	Severity string   `json:"severity"`
+	Action   string   `json:"action"`
+	Rule     string   `json:"rule,omitempty"`
+	Message  string   `json:"message,omitempty"`
+	Reasons  []string `json:"reasons,omitempty"`
+}
+
+// genResult is the JSON shape of snip gen. In JSON mode the script is only proposed; save it
+// with snip add.
+type genResult struct {
+	Name     string        `json:"name"`
+	Script   string        `json:"script"`
+	Params   []model.Param `json:"params,omitempty"`
+	Warnings []string      `json:"warnings,omitempty"`
+	Checks   []genCheck    `json:"checks"`
+}
+
+// handleGen asks the AI provider for a snippet implementing a description, shows it with its
+// variables, parser warnings and danger checks, and saves it once the user accepts or edits it.
+func (h *Handler) handleGen(rest []string) {
+	if len(rest) < 2 {
+		h.info("usage: snip gen <name> \"<description>\"")
+		return
+	}
+	name, description := rest[0], strings.Join(rest[1:], " ")
+
+	svc, err := h.ensureService()
+	if err != nil {
+		h.error(err)
+		return
+	}
+	if err := svc.Available(name); err != nil {
+		h.error(err)
+		return
+	}
+	policy, err := danger.LoadPolicy(h.cfg.Paths.PolicyFile)
+	if err != nil {
+		h.error(errs.Wrap(err, "danger-policy", "failed to load danger policy"))
+		return
+	}
+	gen, err := h.generator()
+	if err != nil {
+		h.error(err)
+		return
+	}
+
+	h.info("[aish] generating snippet...")
+	out, err := gen.Gen(description)
+	if err != nil {
+		h.error(errs.Wrap(err, "snip-gen", "[aish] the AI provider did not return a snippet: "+err.Error()))
+		return
+	}
+	script := cleanScript(out)
+
+	if h.printer.JSON() {
+		res, err := review(policy, name, script)
+		if err != nil {
+			h.error(errs.Wrap(err, "snip-gen-parse", "[aish] the generated script is not a valid snippet: "+err.Error(), errs.WithFields(map[string]string{"script": script})))
+			return
+		}
+		h.printer.Result(res)
+		return
+	}
+
+	for {
+		res, err := review(policy, name, script)
+		if err != nil {
+			h.error(errs.Wrap(err, "snip-gen-parse", "[aish] not a valid snippet: "+err.Error()))
+		} else {
+			h.showReview(res)
+		}
+
+		h.printer.Prompt("[aish] Save it? [y/N/e] (e: edit in $EDITOR) ")
+		switch shell.Choose() {
+		case shell.ChoiceYes:
+			if err != nil {
+				h.warn("[aish] fix the snippet with e, or discard it")
+				continue
+			}
+			if step, blocked := firstBlocked(res.Checks); blocked {
+				h.warn(fmt.Sprintf("[aish] step %d is blocked by the danger policy; edit the snippet or discard it", step))
+				continue
+			}
+			created, _, err := svc.Add(name, script, false)
+			if err != nil {
+				h.error(err)
+				return
+			}
+			if !created {
+				h.warn("Snippet not created")
+				return
+			}
+			h.success(fmt.Sprintf("[aish] snippet %q saved; run it with snip run %s", name, name))
+			return
+		case shell.ChoiceEdit:
+			edited, err := editScript(script)
+			if err != nil {
+				h.error(errs.Wrap(err, "snip-gen-edit", "[aish] cannot edit the snippet: "+err.Error()))
+				return
+			}
+			script = edited
+		default:
+			h.info("[aish] snippet discarded")
+			return
+		}
+	}
+}
+
+// generator builds the AI service the same way the ai commands do, asking for the key
+// passphrase on the terminal when needed.
+func (h *Handler) generator() (*ainternal.Service, error) {
+	gen, err := shared.AIService(&h.cfg, true, "'snip gen'")
+	if err != nil {
+		if _, ok := errs.From(err); ok {
+			return nil, err
+		}
+		return nil, errs.Wrap(err, "ai-init", "[aish] cannot start the AI provider: "+err.Error())
+	}
+	return gen, nil
+}
+
+// review parses script like snip add would and checks each step against the danger policy.
+// Steps are checked as they would run with every variable at its default, so a dangerous
+// default such as rm -rf [[dir:/]] is not hidden behind the placeholder. Required variables,
+// which have no value yet, are checked as <name>.
+func review(policy *danger.Policy, name, script string) (genResult, error) {
+	parsed, err := parser.Build(parser.Normalize(script))
+	if err != nil {
+		return genResult{}, err
+	}
+
+	values := map[string]string{}
+	raw := map[string]bool{}
+	for _, p := range parsed.Params {
+		switch {
+		case p.Default != nil:
+			values[p.Name] = *p.Default
+		case len(p.Choices) > 0:
+			values[p.Name] = p.Choices[0]
+		default:
+			values[p.Name] = "<" + p.Name + ">"
+		}
+		raw[p.Name] = p.Type == model.ParamRaw
+	}
+
+	res := genResult{Name: name, Script: script, Params: parsed.Params, Warnings: parsed.Warnings}
+	scope := danger.CurrentScope()
+	for i, step := range parsed.Steps {
+		command, checked := step.Cmd, ""
+		if command != "" {
+			if checked, err = template.Parse(command).RenderShell(values, raw); err != nil {
+				return genResult{}, err
+			}
+		} else {
+			quoted := make([]string, len(step.Exec))
+			rendered := make([]string, len(step.Exec))
+			for j, arg := range step.Exec {
+				quoted[j] = utils.ShellQuote(arg)
+				v, err := template.Parse(arg).Render(values)
+				if err != nil {
+					return genResult{}, err
+				}
+				rendered[j] = utils.ShellQuote(v)
+			}
+			command, checked = strings.Join(quoted, " "), strings.Join(rendered, " ")
+		}
+		d := policy.Evaluate(checked, scope)
+		check := genCheck{Step: i + 1, Command: command, Checked: checked, Severity: d.Verdict.Severity.String(), Action: string(d.Action), Rule: d.Rule, Message: d.Message}
+		for _, r := range d.Verdict.Reasons {
+			check.Reasons = append(check.Reasons, r.String())
+		}
+		res.Checks = append(res.Checks, check)
+	}
+	return res, nil
+}
+
+func (h *Handler) showReview(res genResult) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "snippet: %s (generated)\nsteps:\n", res.Name)
+	for _, c := range res.Checks {
+		fmt.Fprintf(&b, "[%d] $ %s\n", c.Step, c.Command)
+		if c.Checked != c.Command {
+			fmt.Fprintf(&b, "    checked as: %s\n", c.Checked)
+		}
+	}
+	if len(res.Params) > 0 {
+		b.WriteString("vars:\n")
+		for _, p := range res.Params {
+			fmt.Fprintf(&b, "  %s\n", describeParam(p))
+		}
+	}
+	h.info(strings.TrimRight(b.String(), "\n"))
+
+	for _, warning := range res.Warnings {
+		h.warn("War: " + warning)
+	}
+	for _, c := range res.Checks {
+		for _, reason := range c.Reasons {
+			h.warn(fmt.Sprintf("  step %d: %s", c.Step, reason))
+		}
+		if c.Message != "" {
+			h.warn(fmt.Sprintf("[aish] step %d: policy %q (%s): %s", c.Step, c.Rule, c.Action, c.Message))
+		} else if c.Rule != "" {
+			h.warn(fmt.Sprintf("[aish] step %d: policy %q: %s", c.Step, c.Rule, c.Action))
+		}
+	}
+}
+
+func firstBlocked(checks []genCheck) (int, bool) {
+	for _, c := range checks {
+		if c.Action == string(danger.ActionBlock) {
+			return c.Step, true
+		}
+	}
+	return 0, false
+}
+
+// cleanScript drops what models tend to add around a script despite the prompt: code
+// fences, "$ " prompts, comments and blank lines.
+func cleanScript(out string) string {
+	var lines []string
+	for _, ln := range strings.Split(out, "\n") {
+		ln = strings.TrimSpace(ln)
+		if ln == "" || strings.HasPrefix(ln, "```") || strings.HasPrefix(ln, "#") {
+			continue
+		}
+		lines = append(lines, strings.TrimPrefix(ln, "$ "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// editScript opens script in $VISUAL or $EDITOR (vi by default) and returns the result.
+func editScript(script string) (string, error) {
+	f, err := os.CreateTemp("", "aish-snippet-*.sh")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	header := "# One command per line. Lines starting with # are ignored.\n"
+	if _, err := f.WriteString(header + script + "\n"); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// The editor may carry arguments (e.g. "code --wait"), so let the shell split it.
+	cmd := exec.Command("/bin/sh", "-c", editor+" "+utils.ShellQuote(f.Name()))
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %w", editor, err)
+	}
+
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	return cleanScript(string(b)), nil
+}
@@ -0,0 +1,151 @@
+package snip
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/mr-gaber/ai-shell/internal/errs"
+	"github.com/mr-gaber/ai-shell/internal/shell"
+	"github.com/mr-gaber/ai-shell/internal/snippets/model"
+	"github.com/mr-gaber/ai-shell/internal/snippets/parser"
+)
+
+// paramResult is the JSON shape of snip param.
+type paramResult struct {
+	Name  string      `json:"name"`
+	Param model.Param `json:"param"`
+}
+
+// handleParam shows or edits the type, default and description of one snippet variable.
+func (h *Handler) handleParam(rest []string) {
+	const usage = "usage: snip param <name> <var> [--type int|path|string|raw|a,b,c] [--default value | --required] [--desc text]"
+
+	if len(rest) < 2 {
+		h.info(usage)
+		return
+	}
+	name, varName := rest[0], rest[1]
+
+	fs := flag.NewFlagSet("snip param", flag.ContinueOnError)
+	typ := fs.String("type", "", "value type: int, path, string, raw or comma-separated choices")
+	def := fs.String("default", "", "default value, making the variable optional")
+	required := fs.Bool("required", false, "remove the default")
+	desc := fs.String("desc", "", "description shown when prompting")
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(rest[2:]); err != nil || fs.NArg() != 0 {
+		h.info(usage)
+		return
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["default"] && *required {
+		h.info(usage)
+		return
+	}
+
+	svc, err := h.ensureService()
+	if err != nil {
+		h.error(err)
+		return
+	}
+	snip, err := svc.View(name)
+	if err != nil {
+		h.error(err)
+		return
+	}
+	params := snip.ParamList()
+	i := slices.IndexFunc(params, func(p model.Param) bool { return p.Name == varName })
+	if i < 0 {
+		h.error(errs.New("snip-param-unknown", fmt.Sprintf("[aish] snippet %q has no variable %q", name, varName), errs.WithFields(map[string]string{"name": name, "var": varName})))
+		return
+	}
+	param := params[i]
+
+	if len(set) > 0 {
+		if set["type"] {
+			param.Choices = nil
+			if err := parser.ParseType(&param, *typ); err != nil {
+				h.error(errs.Wrap(err, "snip-param-type", "[aish] "+err.Error()))
+				return
+			}
+		}
+		switch {
+		case set["default"]:
+			param.Default = def
+		case *required:
+			param.Default = nil
+		}
+		if set["desc"] {
+			param.Description = *desc
+		}
+		if err := svc.SetParam(name, param); err != nil {
+			h.error(err)
+			return
+		}
+	}
+
+	if h.printer.JSON() {
+		h.printer.Result(paramResult{Name: name, Param: param})
+		return
+	}
+	if len(set) > 0 {
+		h.success(fmt.Sprintf("[aish] %s: %s updated", name, describeParam(param)))
+		return
+	}
+	h.info(describeParam(param))
+}
+
+// promptParam asks for a required variable on the terminal until the value fits its type.
+func (h *Handler) promptParam(p model.Param) (string, error) {
+	label := p.Name
+	if p.Description != "" {
+		label += " (" + p.Description + ")"
+	}
+	switch p.Type {
+	case model.ParamEnum:
+		label += " [" + strings.Join(p.Choices, "/") + "]"
+	case model.ParamInt, model.ParamPath, model.ParamRaw:
+		label += " <" + p.Type + ">"
+	}
+
+	for attempt := 0; attempt < 3; attempt++ {
+		h.printer.Prompt(fmt.Sprintf("[aish] %s: ", label))
+		line, err := shell.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(line) == "" {
+			h.warn("[aish] a value is required")
+			continue
+		}
+		if err := parser.ValidateValue(p, line); err != nil {
+			h.warn("[aish] " + err.Error())
+			continue
+		}
+		return line, nil
+	}
+	return "", errors.New("no valid value after 3 attempts")
+}
+
+// describeParam renders a param as it would be written in a placeholder, plus its description.
+func describeParam(p model.Param) string {
+	s := p.Name
+	switch p.Type {
+	case model.ParamEnum:
+		s += "|" + strings.Join(p.Choices, ",")
+	case model.ParamInt, model.ParamPath, model.ParamRaw:
+		s += "|" + p.Type
+	}
+	if p.Default != nil {
+		s += ":" + *p.Default
+	}
+	s = "[[" + s + "]]"
+	if p.Description != "" {
+		s += "  " + p.Description
+	}
+	return s
+}
@@ -0,0 +1,168 @@
+package snip
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mr-gaber/ai-shell/internal/errs"
+	"github.com/mr-gaber/ai-shell/internal/snippets/record"
+)
+
+// recordResult is the JSON shape of snip record.
+type recordResult struct {
+	Name    string    `json:"name"`
+	Started time.Time `json:"started"`
+}
+
+// stopResult is the JSON shape of snip stop.
+type stopResult struct {
+	Name      string       `json:"name"`
+	Discarded bool         `json:"discarded,omitempty"`
+	Steps     []string     `json:"steps,omitempty"`
+	Vars      []record.Var `json:"vars,omitempty"`
+	Skipped   int          `json:"skipped,omitempty"`
+	Warnings  []string     `json:"warnings,omitempty"`
+}
+
+// handleRecord starts recording the commands of this session into a new snippet.
+func (h *Handler) handleRecord(rest []string) {
+	if len(rest) != 1 {
+		h.info("usage: snip record <name>")
+		return
+	}
+	name := rest[0]
+
+	historyFile, err := h.sessionHistory("record")
+	if err != nil {
+		h.error(err)
+		return
+	}
+	svc, err := h.ensureService()
+	if err != nil {
+		h.error(err)
+		return
+	}
+	if err := svc.Available(name); err != nil {
+		h.error(err)
+		return
+	}
+
+	rec, err := record.Start(historyFile, name)
+	if err != nil {
+		h.error(errs.Wrap(err, "snip-record-start", "[aish] cannot start recording: "+err.Error()))
+		return
+	}
+
+	if h.printer.JSON() {
+		h.printer.Result(recordResult{Name: rec.Name, Started: rec.Started})
+		return
+	}
+	h.success(fmt.Sprintf("[aish] recording %q; run your commands, then snip stop (or snip stop --discard)", name))
+}
+
+// handleStop ends the recording and saves the successful commands run since snip record.
+func (h *Handler) handleStop(rest []string) {
+	discard := len(rest) == 1 && rest[0] == "--discard"
+	if len(rest) > 0 && !discard {
+		h.info("usage: snip stop [--discard]")
+		return
+	}
+
+	historyFile, err := h.sessionHistory("stop")
+	if err != nil {
+		h.error(err)
+		return
+	}
+	rec, err := record.Current(historyFile)
+	if errors.Is(err, record.ErrNotRecording) {
+		h.error(errs.New("snip-record-none", "[aish] nothing is being recorded; start with snip record <name>"))
+		return
+	}
+	if err != nil {
+		h.error(errs.Wrap(err, "snip-record-read", "[aish] "+err.Error()))
+		return
+	}
+
+	if discard {
+		if err := record.Clear(historyFile); err != nil {
+			h.error(errs.Wrap(err, "snip-record-clear", "[aish] cannot end recording"))
+			return
+		}
+		if h.printer.JSON() {
+			h.printer.Result(stopResult{Name: rec.Name, Discarded: true})
+			return
+		}
+		h.info(fmt.Sprintf("[aish] recording %q discarded", rec.Name))
+		return
+	}
+
+	cmds, skipped, err := record.Commands(historyFile, rec)
+	if err != nil {
+		h.error(errs.Wrap(err, "snip-record-read", "[aish] "+err.Error()))
+		return
+	}
+	if len(cmds) == 0 {
+		_ = record.Clear(historyFile)
+		h.error(errs.New("snip-record-empty", fmt.Sprintf("[aish] no successful commands since snip record %s; nothing saved", rec.Name),
+			errs.WithFields(map[string]string{"name": rec.Name, "skipped": fmt.Sprintf("%d", skipped)})))
+		return
+	}
+
+	steps, vars := record.Generalize(cmds)
+	svc, err := h.ensureService()
+	if err != nil {
+		h.error(err)
+		return
+	}
+	// The recording stays active when saving fails, so the user can fix the cause and stop again.
+	created, warnings, err := svc.Add(rec.Name, strings.Join(steps, "\n"), false)
+	if err != nil {
+		h.error(err)
+		return
+	}
+	if !created {
+		h.warn("Snippet not created")
+		return
+	}
+	if err := record.Clear(historyFile); err != nil {
+		h.error(errs.Wrap(err, "snip-record-clear", "[aish] snippet saved, but the recording marker could not be removed"))
+		return
+	}
+	warnings = append(warnings, cdWarnings(steps)...)
+
+	if h.printer.JSON() {
+		h.printer.Result(stopResult{Name: rec.Name, Steps: steps, Vars: vars, Skipped: skipped, Warnings: warnings})
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "snippet: %s\nsteps:\n", rec.Name)
+	for i, step := range steps {
+		fmt.Fprintf(&b, "[%d] $ %s\n", i+1, step)
+	}
+	if len(vars) > 0 {
+		b.WriteString("vars (from repeated values):\n")
+		for _, v := range vars {
+			fmt.Fprintf(&b, "  %s = %s (%d uses)\n", v.Name, v.Value, v.Uses)
+		}
+	}
+	h.info(strings.TrimRight(b.String(), "\n"))
+	for _, warning := range warnings {
+		h.warn("War: " + warning)
+	}
+	if skipped > 0 {
+		h.info(fmt.Sprintf("[aish] left out %d failed or multi-line command(s)", skipped))
+	}
+	h.success(fmt.Sprintf("[aish] snippet %q saved from %d command(s)", rec.Name, len(steps)))
+}
+
+// sessionHistory returns the history file of the current aish session, which record, stop
+// and save read from.
+func (h *Handler) sessionHistory(sub string) (string, error) {
+	if h.cfg.Paths.HistoryFile == "" {
+		return "", errs.New("snip-no-session", fmt.Sprintf("[aish] snip %s only works inside an aish session", sub))
+	}
+	return h.cfg.Paths.HistoryFile, nil
+}
@@ -0,0 +1,220 @@
+package snip
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mr-gaber/ai-shell/internal/errs"
+	"github.com/mr-gaber/ai-shell/internal/session/history"
+	"github.com/mr-gaber/ai-shell/internal/snippets/record"
+)
+
+// savedCommand is a history entry as shown by snip save.
+type savedCommand struct {
+	Seq  int    `json:"seq"`
+	Cmd  string `json:"cmd"`
+	Exit int    `json:"exit"`
+	CWD  string `json:"cwd,omitempty"`
+}
+
+// saveResult is the JSON shape of snip save.
+type saveResult struct {
+	Name     string         `json:"name,omitempty"`
+	Commands []savedCommand `json:"commands"`
+	Warnings []string       `json:"warnings,omitempty"`
+}
+
+// handleSave stores commands from the session history as a snippet: the last successful
+// command by default, the last N with --last, or the ones picked by sequence number with --seq.
+func (h *Handler) handleSave(rest []string) {
+	const usage = "usage: snip save <name> [--last N | --seq id|from-to]\n  snip save --list [N]"
+
+	if len(rest) < 1 {
+		h.info(usage)
+		return
+	}
+	if rest[0] == "--list" {
+		h.listHistory(rest[1:], usage)
+		return
+	}
+	name := rest[0]
+
+	fs := flag.NewFlagSet("snip save", flag.ContinueOnError)
+	last := fs.Int("last", 1, "save the last N successful commands")
+	seq := fs.String("seq", "", "save the command with this sequence number, or a from-to range")
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(rest[1:]); err != nil || fs.NArg() != 0 || *last < 1 {
+		h.info(usage)
+		return
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["last"] && set["seq"] {
+		h.info(usage)
+		return
+	}
+
+	historyFile, err := h.sessionHistory("save")
+	if err != nil {
+		h.error(err)
+		return
+	}
+	svc, err := h.ensureService()
+	if err != nil {
+		h.error(err)
+		return
+	}
+	if err := svc.Available(name); err != nil {
+		h.error(err)
+		return
+	}
+	entries, err := history.All(historyFile)
+	if err != nil {
+		h.error(errs.Wrap(err, "snip-save-history", "[aish] "+err.Error()))
+		return
+	}
+
+	var picked []history.Entry
+	if set["seq"] {
+		from, to, ok := parseSeq(*seq)
+		if !ok {
+			h.info(usage)
+			return
+		}
+		if picked, err = record.Seq(entries, from, to); err != nil {
+			h.error(errs.Wrap(err, "snip-save-seq", "[aish] "+err.Error()+"; see snip save --list", errs.WithFields(map[string]string{"seq": *seq})))
+			return
+		}
+	} else if picked = record.Last(entries, *last); len(picked) == 0 {
+		h.error(errs.New("snip-save-empty", "[aish] no successful commands in this session's history yet"))
+		return
+	}
+
+	var warnings []string
+	cmds := make([]string, len(picked))
+	shown := make([]savedCommand, len(picked))
+	for i, e := range picked {
+		cmds[i] = strings.TrimSpace(e.Cmd)
+		shown[i] = savedCommand{Seq: e.Seq, Cmd: cmds[i], Exit: e.Exit, CWD: e.CWD}
+		if e.Exit != 0 {
+			warnings = append(warnings, fmt.Sprintf("[aish] command %d exited %d when it ran; saved anyway", e.Seq, e.Exit))
+		}
+	}
+	if set["last"] && len(picked) < *last {
+		warnings = append(warnings, fmt.Sprintf("[aish] only %d successful command(s) in history; saved all of them", len(picked)))
+	}
+
+	created, parseWarnings, err := svc.Add(name, strings.Join(cmds, "\n"), false)
+	if err != nil {
+		h.error(err)
+		return
+	}
+	if !created {
+		h.warn("Snippet not created")
+		return
+	}
+	warnings = append(warnings, parseWarnings...)
+	warnings = append(warnings, cdWarnings(cmds)...)
+
+	if h.printer.JSON() {
+		h.printer.Result(saveResult{Name: name, Commands: shown, Warnings: warnings})
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "snippet: %s\nsteps:\n", name)
+	for i, c := range shown {
+		fmt.Fprintf(&b, "[%d] $ %s\n", i+1, c.Cmd)
+	}
+	h.info(strings.TrimRight(b.String(), "\n"))
+	for _, warning := range warnings {
+		h.warn("War: " + warning)
+	}
+	h.success(fmt.Sprintf("[aish] snippet %q saved from %d command(s)", name, len(shown)))
+}
+
+// listHistory prints the recent commands of the session with the sequence numbers --seq takes.
+func (h *Handler) listHistory(rest []string, usage string) {
+	n := 20
+	if len(rest) > 1 {
+		h.info(usage)
+		return
+	}
+	if len(rest) == 1 {
+		v, err := strconv.Atoi(rest[0])
+		if err != nil || v < 1 {
+			h.info(usage)
+			return
+		}
+		n = v
+	}
+
+	historyFile, err := h.sessionHistory("save")
+	if err != nil {
+		h.error(err)
+		return
+	}
+	entries, err := history.All(historyFile)
+	if err != nil {
+		h.error(errs.Wrap(err, "snip-save-history", "[aish] "+err.Error()))
+		return
+	}
+
+	shown := make([]savedCommand, 0, n)
+	for i := len(entries) - 1; i >= 0 && len(shown) < n; i-- {
+		e := entries[i]
+		if history.IsHelper(e.Cmd) {
+			continue
+		}
+		shown = append(shown, savedCommand{Seq: e.Seq, Cmd: strings.TrimSpace(e.Cmd), Exit: e.Exit, CWD: e.CWD})
+	}
+	// Oldest first, as in shell history.
+	for i, j := 0, len(shown)-1; i < j; i, j = i+1, j-1 {
+		shown[i], shown[j] = shown[j], shown[i]
+	}
+
+	if h.printer.JSON() {
+		h.printer.Result(saveResult{Commands: shown})
+		return
+	}
+	if len(shown) == 0 {
+		h.info("[aish] no commands in this session's history yet")
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%5s %4s  %s\n", "seq", "exit", "command")
+	for _, c := range shown {
+		fmt.Fprintf(&b, "%5d %4d  %s\n", c.Seq, c.Exit, truncate(strings.ReplaceAll(c.Cmd, "\n", " "), 80))
+	}
+	h.info(strings.TrimRight(b.String(), "\n"))
+}
+
+// parseSeq accepts "12" or "12-15".
+func parseSeq(s string) (from, to int, ok bool) {
+	a, b, isRange := strings.Cut(s, "-")
+	from, err := strconv.Atoi(a)
+	if err != nil || from < 1 {
+		return 0, 0, false
+	}
+	if !isRange {
+		return from, from, true
+	}
+	to, err = strconv.Atoi(b)
+	if err != nil || to < from {
+		return 0, 0, false
+	}
+	return from, to, true
+}
+
+// cdWarnings flags steps that change directory, which has no effect on later steps.
+func cdWarnings(steps []string) []string {
+	var out []string
+	for i, step := range steps {
+		if step == "cd" || strings.HasPrefix(step, "cd ") {
+			out = append(out, fmt.Sprintf("[aish] step %d changes directory; snip run starts each step in a new shell, so later steps will not see it (snip run --inject keeps one shell)", i+1))
+		}
+	}
+	return out
+}
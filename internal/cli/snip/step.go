@@ -0,0 +1,132 @@
+package snip
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mr-gaber/ai-shell/internal/snippets/model"
+	"github.com/mr-gaber/ai-shell/internal/snippets/service"
+)
+
+// stepResult is the JSON shape of snip step.
+type stepResult struct {
+	Name string     `json:"name"`
+	Step int        `json:"step"`
+	Opts model.Step `json:"options"`
+}
+
+// handleStep shows or sets the flow options of one step (1-based, as shown by snip view).
+// Setting any option replaces all of them, so the command line always shows the full result.
+func (h *Handler) handleStep(rest []string) {
+	const usage = "usage: snip step <name> <n> [--when cond] [--retries N] [--backoff 1s] [--timeout 30s] [--continue-on-error]"
+
+	if len(rest) < 2 {
+		h.info(usage)
+		return
+	}
+	name := rest[0]
+	index, err := strconv.Atoi(rest[1])
+	if err != nil {
+		h.info(usage)
+		return
+	}
+
+	var opts model.Step
+	fs := flag.NewFlagSet("snip step", flag.ContinueOnError)
+	fs.StringVar(&opts.When, "when", "", "condition on earlier exit codes or env vars")
+	fs.IntVar(&opts.Retries, "retries", 0, "re-run a failing step up to N times")
+	fs.StringVar(&opts.Backoff, "backoff", "", "wait before the first retry, doubled each time (default 1s)")
+	fs.StringVar(&opts.Timeout, "timeout", "", "kill an attempt after this long")
+	fs.BoolVar(&opts.ContinueOnError, "continue-on-error", false, "go on with the next step if this one fails")
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(rest[2:]); err != nil || fs.NArg() != 0 {
+		h.info(usage)
+		return
+	}
+
+	svc, err := h.ensureService()
+	if err != nil {
+		h.error(err)
+		return
+	}
+
+	var step model.Step
+	if fs.NFlag() == 0 {
+		snip, err := svc.View(name)
+		if err != nil {
+			h.error(err)
+			return
+		}
+		if index < 1 || index > len(snip.Steps) {
+			h.info(fmt.Sprintf("[aish] snippet %q has steps 1-%d", name, len(snip.Steps)))
+			return
+		}
+		step = snip.Steps[index-1]
+	} else if step, err = svc.SetStep(name, index, opts); err != nil {
+		h.error(err)
+		return
+	}
+
+	if h.printer.JSON() {
+		h.printer.Result(stepResult{Name: name, Step: index, Opts: step})
+		return
+	}
+	msg := fmt.Sprintf("[aish] %s step %d: %s", name, index, describeFlow(step))
+	if fs.NFlag() == 0 {
+		h.info(msg)
+		return
+	}
+	h.success(msg)
+}
+
+// describeFlow renders a step's flow options as flags, or "default" when it has none.
+func describeFlow(step model.Step) string {
+	var parts []string
+	if step.When != "" {
+		parts = append(parts, fmt.Sprintf("--when %q", step.When))
+	}
+	if step.Retries > 0 {
+		parts = append(parts, fmt.Sprintf("--retries %d", step.Retries))
+		if step.Backoff != "" {
+			parts = append(parts, "--backoff "+step.Backoff)
+		}
+	}
+	if step.Timeout != "" {
+		parts = append(parts, "--timeout "+step.Timeout)
+	}
+	if step.ContinueOnError {
+		parts = append(parts, "--continue-on-error")
+	}
+	if len(parts) == 0 {
+		return "default (run once, stop on failure)"
+	}
+	return strings.Join(parts, " ")
+}
+
+// summary prints a table of step outcomes after a run.
+func (h *Handler) summary(results []service.StepResult) {
+	if len(results) == 0 {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%-4s %-8s %-5s %-8s %-9s %s\n", "step", "status", "exit", "attempts", "time", "command")
+	for _, r := range results {
+		exit, attempts, took := "-", "-", "-"
+		if r.Attempts > 0 {
+			exit, attempts, took = strconv.Itoa(r.Exit), strconv.Itoa(r.Attempts), r.Duration.Round(time.Millisecond).String()
+		}
+		fmt.Fprintf(&b, "%-4d %-8s %-5s %-8s %-9s %s\n", r.Step, r.Status, exit, attempts, took, truncate(r.Command, 60))
+	}
+	h.info(strings.TrimRight(b.String(), "\n"))
+}
+
+func truncate(s string, n int) string {
+	if len([]rune(s)) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
@@ -1,51 +1,67 @@
 package config
 
-import (
-	"os"
-	"strings"
-)
+import "time"
 
-// Config captures environment-driven settings and derived defaults used across the app.
+// Config captures the effective settings used across the app. It is resolved by Load from
+// defaults, ~/.aish/config.yaml, the nearest .aish.yaml and environment overrides; see Keys.
 type Config struct {
-	Paths      Paths
-	Limits     Limits
-	AIProvider string
-	OpenAIKey  string
+	Paths       Paths
+	Limits      Limits
+	Fix         Fix
+	Hints       Hints
+	Credentials Credentials
+	AIProvider  string
+	OpenAIKey   string
+	// AIModel, AITemperature and AIPrompt tune requests; a matching profile may override them.
+	AIModel       string
+	AITemperature float64
+	AIPrompt      string
+	// Profile names the profile selected for the working directory, if any.
+	Profile string
 }
 
-// Paths groups filesystem locations discovered from the environment.
+// Paths groups filesystem locations.
 type Paths struct {
 	SnippetsFile string
 	SessionLog   string
 	HistoryFile  string
+	RedactFile   string
+	PolicyFile   string
+	EvalFile     string
+	ControlSock  string
+	// CredentialsFile is the encrypted key store managed by aish key.
+	CredentialsFile string
 }
 
-// Limits collects numeric tuning knobs sourced from env vars.
+// Limits collects numeric tuning knobs.
 type Limits struct {
 	TailLines    int
 	TailMaxBytes int
 	HistorySize  int
+	// ProjectBudget caps each project context section in bytes; zero disables project context.
+	ProjectBudget int
+	// AttachMaxBytes caps each file or stdin attachment passed to ai ask.
+	AttachMaxBytes int
 }
 
-// LoadFromEnv constructs a Config populated from environment variables, applying defaults.
-func LoadFromEnv() Config {
-	provider := strings.TrimSpace(os.Getenv("AI_PROVIDER"))
-	if provider == "" {
-		provider = "openai"
-	}
+// Fix holds defaults for the ai fix workflow.
+type Fix struct {
+	DryRun        bool
+	Verify        bool
+	MaxIterations int
+}
+
+// Hints controls automatic one-line hints after failed commands.
+type Hints struct {
+	Enabled  bool
+	Debounce time.Duration
+	Ignore   []string
+}
 
-	return Config{
-		Paths: Paths{
-			SnippetsFile: strings.TrimSpace(os.Getenv("AISH_SNIPPETS_FILE")),
-			SessionLog:   strings.TrimSpace(os.Getenv("AISH_SESSION_LOG")),
-			HistoryFile:  strings.TrimSpace(os.Getenv("AISH_HISTORY_FILE")),
-		},
-		Limits: Limits{
-			TailLines:    intDefault("AISH_TAIL_LINES", 120),
-			TailMaxBytes: intDefault("AISH_TAIL_MAX_BYTES", 256<<10),
-			HistorySize:  intDefault("AISH_HISTORY_SIZE", 5),
-		},
-		AIProvider: provider,
-		OpenAIKey:  strings.TrimSpace(os.Getenv("OPENAI_API_KEY")),
-	}
+// Credentials controls how unlocked API keys are cached.
+type Credentials struct {
+	// CacheTTL is how long a key decrypted from the credentials file stays in the kernel keyring.
+	// Zero, the default, disables the cache: the user keyring is readable by every process of
+	// the same user, including those started in the aish shell.
+	CacheTTL time.Duration
 }
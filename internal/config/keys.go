@@ -0,0 +1,197 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the value type of a configuration key.
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindPath
+	KindInt
+	KindFloat
+	KindBool
+	KindList
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindPath:
+		return "path"
+	case KindInt:
+		return "integer"
+	case KindFloat:
+		return "number"
+	case KindBool:
+		return "boolean"
+	case KindList:
+		return "list"
+	default:
+		return "string"
+	}
+}
+
+// Scope limits which layers may set a key.
+type Scope int
+
+const (
+	// ScopeAny keys may be set in the user file, a project file or the environment.
+	ScopeAny Scope = iota
+	// ScopeUser keys may not come from a project file, so a checked-out repository cannot
+	// point aish at its own policy or redaction rules, switch its AI backend or add
+	// instructions to its prompts.
+	ScopeUser
+	// ScopeEnv keys are per-session or secret and only come from the environment.
+	ScopeEnv
+)
+
+// Key describes one configuration setting: its dotted name in config files, its env override,
+// its type and default, and how a parsed value is stored on Config.
+type Key struct {
+	Name    string
+	Env     string
+	Kind    Kind
+	Default string
+	Scope   Scope
+	Min     int
+	Max     int // zero means unbounded
+	Enum    []string
+	Secret  bool
+	Doc     string
+
+	assign func(c *Config, v any)
+}
+
+// Keys is the registry of every supported configuration key, in display order.
+var Keys = []Key{
+	{Name: "ai.provider", Env: "AI_PROVIDER", Kind: KindString, Default: "openai", Enum: []string{"openai", "ollama"}, Scope: ScopeUser, Doc: "AI backend",
+		assign: func(c *Config, v any) { c.AIProvider = v.(string) }},
+	{Name: "ai.model", Env: "AI_MODEL", Kind: KindString, Default: "gpt-4o-mini", Doc: "model name passed to the provider",
+		assign: func(c *Config, v any) { c.AIModel = v.(string) }},
+	{Name: "ai.temperature", Env: "AI_TEMPERATURE", Kind: KindFloat, Default: "1", Min: 0, Max: 2, Doc: "sampling temperature",
+		assign: func(c *Config, v any) { c.AITemperature = v.(float64) }},
+	{Name: "ai.prompt", Env: "AI_PROMPT", Kind: KindString, Scope: ScopeUser, Doc: "extra instructions appended to every system prompt",
+		assign: func(c *Config, v any) { c.AIPrompt = v.(string) }},
+	{Name: "openai.api_key", Env: "OPENAI_API_KEY", Kind: KindString, Scope: ScopeEnv, Secret: true, Doc: "OpenAI API key",
+		assign: func(c *Config, v any) { c.OpenAIKey = v.(string) }},
+
+	{Name: "paths.snippets", Env: "AISH_SNIPPETS_FILE", Kind: KindPath, Default: "~/.aish/snippets.yaml", Scope: ScopeUser, Doc: "snippet store",
+		assign: func(c *Config, v any) { c.Paths.SnippetsFile = v.(string) }},
+	{Name: "paths.redact", Env: "AISH_REDACT_FILE", Kind: KindPath, Default: "~/.aish/redact.yaml", Scope: ScopeUser, Doc: "redaction rules",
+		assign: func(c *Config, v any) { c.Paths.RedactFile = v.(string) }},
+	{Name: "paths.policy", Env: "AISH_POLICY_FILE", Kind: KindPath, Default: "~/.aish/policy.yaml", Scope: ScopeUser, Doc: "danger policy",
+		assign: func(c *Config, v any) { c.Paths.PolicyFile = v.(string) }},
+	{Name: "paths.credentials", Env: "AISH_CREDENTIALS_FILE", Kind: KindPath, Default: "~/.aish/credentials.enc", Scope: ScopeUser, Doc: "encrypted API keys",
+		assign: func(c *Config, v any) { c.Paths.CredentialsFile = v.(string) }},
+	{Name: "paths.session_log", Env: "AISH_SESSION_LOG", Kind: KindPath, Scope: ScopeEnv, Doc: "session transcript (set by the launcher)",
+		assign: func(c *Config, v any) { c.Paths.SessionLog = v.(string) }},
+	{Name: "paths.history", Env: "AISH_HISTORY_FILE", Kind: KindPath, Scope: ScopeEnv, Doc: "session history (set by the launcher)",
+		assign: func(c *Config, v any) { c.Paths.HistoryFile = v.(string) }},
+	{Name: "paths.eval_file", Env: "AISH_EVAL_FILE", Kind: KindPath, Scope: ScopeEnv, Doc: "eval file (set by the ai shell function)",
+		assign: func(c *Config, v any) { c.Paths.EvalFile = v.(string) }},
+	{Name: "paths.control_socket", Env: "AISH_CONTROL_SOCKET", Kind: KindPath, Scope: ScopeEnv, Doc: "session control socket (set by the launcher)",
+		assign: func(c *Config, v any) { c.Paths.ControlSock = v.(string) }},
+
+	{Name: "limits.tail_lines", Env: "AISH_TAIL_LINES", Kind: KindInt, Default: "120", Min: 1, Doc: "lines read from history/log files",
+		assign: func(c *Config, v any) { c.Limits.TailLines = v.(int) }},
+	{Name: "limits.tail_max_bytes", Env: "AISH_TAIL_MAX_BYTES", Kind: KindInt, Default: strconv.Itoa(256 << 10), Min: 1, Doc: "byte limit for tail operations",
+		assign: func(c *Config, v any) { c.Limits.TailMaxBytes = v.(int) }},
+	{Name: "limits.history_size", Env: "AISH_HISTORY_SIZE", Kind: KindInt, Default: "5", Min: 1, Doc: "multiplier for history lines in context",
+		assign: func(c *Config, v any) { c.Limits.HistorySize = v.(int) }},
+	{Name: "limits.project_budget", Env: "AISH_PROJECT_BUDGET", Kind: KindInt, Default: "1500", Min: 0, Doc: "bytes per project context section (0 disables)",
+		assign: func(c *Config, v any) { c.Limits.ProjectBudget = v.(int) }},
+	{Name: "limits.attach_max_bytes", Env: "AISH_ATTACH_MAX_BYTES", Kind: KindInt, Default: strconv.Itoa(64 << 10), Min: 1, Doc: "byte limit per ai ask attachment",
+		assign: func(c *Config, v any) { c.Limits.AttachMaxBytes = v.(int) }},
+
+	{Name: "fix.dry_run", Env: "AISH_DRY_RUN", Kind: KindBool, Default: "false", Doc: "sandbox-preview every ai fix",
+		assign: func(c *Config, v any) { c.Fix.DryRun = v.(bool) }},
+	{Name: "fix.verify", Env: "AISH_FIX_VERIFY", Kind: KindBool, Default: "false", Doc: "re-run the failed command after ai fix",
+		assign: func(c *Config, v any) { c.Fix.Verify = v.(bool) }},
+	{Name: "fix.max_iterations", Env: "AISH_FIX_MAX_ITERATIONS", Kind: KindInt, Default: "3", Min: 1, Doc: "fix attempts with --verify",
+		assign: func(c *Config, v any) { c.Fix.MaxIterations = v.(int) }},
+
+	{Name: "credentials.cache_ttl", Env: "AISH_KEY_CACHE_TTL", Kind: KindInt, Default: "0", Min: 0, Doc: "seconds an unlocked key stays in the kernel keyring (0 disables)",
+		assign: func(c *Config, v any) { c.Credentials.CacheTTL = time.Duration(v.(int)) * time.Second }},
+
+	{Name: "hints.enabled", Env: "AISH_AUTO_HINT", Kind: KindBool, Default: "false", Doc: "background hints after failed commands",
+		assign: func(c *Config, v any) { c.Hints.Enabled = v.(bool) }},
+	{Name: "hints.debounce", Env: "AISH_HINT_DEBOUNCE", Kind: KindInt, Default: "30", Min: 0, Doc: "seconds between hints",
+		assign: func(c *Config, v any) { c.Hints.Debounce = time.Duration(v.(int)) * time.Second }},
+	{Name: "hints.ignore", Env: "AISH_HINT_IGNORE", Kind: KindList, Default: "grep,egrep,fgrep,rg,diff,cmp,test,[,false,which,type,command", Doc: "commands whose failures never get hints",
+		assign: func(c *Config, v any) { c.Hints.Ignore = v.([]string) }},
+}
+
+// SecretKeys returns the keys holding credentials, which must not leak into child processes.
+func SecretKeys() []Key {
+	var out []Key
+	for _, k := range Keys {
+		if k.Secret {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// Lookup returns the registered key with the given dotted name.
+func Lookup(name string) (Key, bool) {
+	i := slices.IndexFunc(Keys, func(k Key) bool { return k.Name == name })
+	if i < 0 {
+		return Key{}, false
+	}
+	return Keys[i], true
+}
+
+// Parse converts a raw value to the key's type and validates it.
+func (k Key) Parse(raw string) (any, error) {
+	raw = strings.TrimSpace(raw)
+	switch k.Kind {
+	case KindInt:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("expected an integer, got %q", raw)
+		}
+		if n < k.Min {
+			return nil, fmt.Errorf("must be at least %d, got %d", k.Min, n)
+		}
+		if k.Max != 0 && n > k.Max {
+			return nil, fmt.Errorf("must be at most %d, got %d", k.Max, n)
+		}
+		return n, nil
+	case KindFloat:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected a number, got %q", raw)
+		}
+		if f < float64(k.Min) || (k.Max != 0 && f > float64(k.Max)) {
+			return nil, fmt.Errorf("must be between %d and %d, got %s", k.Min, k.Max, raw)
+		}
+		return f, nil
+	case KindBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false, got %q", raw)
+		}
+		return b, nil
+	case KindList:
+		out := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+		return out, nil
+	case KindPath:
+		return expandHome(raw), nil
+	default:
+		if len(k.Enum) > 0 && !slices.Contains(k.Enum, raw) {
+			return nil, fmt.Errorf("must be one of %s, got %q", strings.Join(k.Enum, ", "), raw)
+		}
+		return raw, nil
+	}
+}
@@ -0,0 +1,319 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mr-gaber/ai-shell/internal/errs"
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFileName is the per-project config file looked up from the working directory upwards.
+const ProjectFileName = ".aish.yaml"
+
+// Source names the layer an effective value came from.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceUser    Source = "user"
+	SourceProject Source = "project"
+	SourceProfile Source = "profile"
+	SourceEnv     Source = "env"
+)
+
+// Origin records where an effective value was set.
+type Origin struct {
+	Source Source
+	Path   string
+	Line   int
+	Env    string
+	// Profile names the profile for SourceProfile; Path and Line locate the setting in the user file.
+	Profile string
+}
+
+func (o Origin) String() string {
+	switch o.Source {
+	case SourceUser, SourceProject:
+		return fmt.Sprintf("%s %s:%d", o.Source, o.Path, o.Line)
+	case SourceProfile:
+		return fmt.Sprintf("profile %s (%s:%d)", o.Profile, o.Path, o.Line)
+	case SourceEnv:
+		return "env " + o.Env
+	default:
+		return string(o.Source)
+	}
+}
+
+// Setting is the effective raw value of one key and where it came from.
+type Setting struct {
+	Key    Key
+	Raw    string
+	Origin Origin
+}
+
+// Value returns the raw value for display, masking secrets.
+func (s Setting) Value() string {
+	if s.Key.Secret && s.Raw != "" {
+		return "********"
+	}
+	return s.Raw
+}
+
+// Settings holds every registered key resolved across all layers, in registry order.
+type Settings struct {
+	list     []Setting
+	profiles []Profile
+	active   *ProfileMatch
+}
+
+// All returns every setting in registry order.
+func (s *Settings) All() []Setting {
+	return s.list
+}
+
+// Get returns the setting for a dotted key name.
+func (s *Settings) Get(name string) (Setting, bool) {
+	for _, st := range s.list {
+		if st.Key.Name == name {
+			return st, true
+		}
+	}
+	return Setting{}, false
+}
+
+// Profiles returns the profiles defined in the user file.
+func (s *Settings) Profiles() []Profile {
+	return s.profiles
+}
+
+// ActiveProfile returns the profile selected for the resolved directory.
+func (s *Settings) ActiveProfile() (ProfileMatch, bool) {
+	if s.active == nil {
+		return ProfileMatch{}, false
+	}
+	return *s.active, true
+}
+
+// Config builds the typed configuration from the resolved settings.
+func (s *Settings) Config() Config {
+	var c Config
+	for _, st := range s.list {
+		v, err := st.Key.Parse(st.Raw)
+		if err != nil {
+			// Resolve only keeps values that parse, so only a broken default lands here.
+			v, _ = st.Key.Parse(st.Key.Default)
+		}
+		st.Key.assign(&c, v)
+	}
+	if s.active != nil {
+		c.Profile = s.active.Profile.Name
+	}
+	return c
+}
+
+// Load resolves the configuration for the current directory.
+func Load() (Config, []error) {
+	dir, _ := os.Getwd()
+	s, problems := Resolve(dir)
+	return s.Config(), problems
+}
+
+// UserFile returns the path of the user-wide config file.
+func UserFile() string {
+	return expandHome("~/.aish/config.yaml")
+}
+
+// FindProjectFile returns the nearest project config file in dir or its parents, or "".
+func FindProjectFile(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Resolve layers defaults, the user file, the profile matching dir, the nearest project file
+// above dir and the environment, later layers winning. Invalid values are reported and skipped,
+// so the previous layer's value stays in effect. Profiles can only be defined in the user file.
+func Resolve(dir string) (*Settings, []error) {
+	s := &Settings{list: make([]Setting, len(Keys))}
+	for i, k := range Keys {
+		s.list[i] = Setting{Key: k, Raw: k.Default, Origin: Origin{Source: SourceDefault}}
+	}
+
+	var problems []error
+	user := UserFile()
+	values, profiles, err := readFile(user)
+	if err != nil {
+		problems = append(problems, err)
+	}
+	problems = append(problems, s.applyFile(values, Origin{Source: SourceUser, Path: user})...)
+	if profiles != nil {
+		parsed, perrs := parseProfiles(user, profiles)
+		s.profiles = parsed
+		problems = append(problems, perrs...)
+	}
+
+	if m, ok := SelectProfile(s.profiles, dir); ok {
+		s.active = &m
+		problems = append(problems, s.applyFile(m.Profile.values, Origin{Source: SourceProfile, Path: user, Profile: m.Profile.Name})...)
+	}
+
+	if project := FindProjectFile(dir); project != "" {
+		values, profiles, err := readFile(project)
+		if err != nil {
+			problems = append(problems, err)
+		}
+		if profiles != nil {
+			problems = append(problems, errs.New("config-scope", fmt.Sprintf("[aish] %s:%d: profiles can only be defined in %s", project, profiles.Line, user),
+				errs.WithFields(map[string]string{"file": project}), errs.WithSeverity(errs.SeverityWarn)))
+		}
+		problems = append(problems, s.applyFile(values, Origin{Source: SourceProject, Path: project})...)
+	}
+
+	for _, k := range Keys {
+		if v, ok := os.LookupEnv(k.Env); ok && strings.TrimSpace(v) != "" {
+			problems = append(problems, s.apply(k.Name, v, Origin{Source: SourceEnv, Env: k.Env})...)
+		}
+	}
+	return s, problems
+}
+
+// applyFile applies values read from one file; origin carries the source and path.
+func (s *Settings) applyFile(values []fileValue, origin Origin) []error {
+	var problems []error
+	for _, fv := range values {
+		o := origin
+		o.Line = fv.line
+		problems = append(problems, s.apply(fv.name, fv.raw, o)...)
+	}
+	return problems
+}
+
+func (s *Settings) apply(name, raw string, origin Origin) []error {
+	fields := errs.WithFields(map[string]string{"key": name, "source": origin.String()})
+	warn := errs.WithSeverity(errs.SeverityWarn)
+	i := -1
+	for j := range s.list {
+		if s.list[j].Key.Name == name {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return []error{errs.New("config-unknown-key", fmt.Sprintf("[aish] unknown config key %q", name), fields, warn)}
+	}
+
+	k := s.list[i].Key
+	if err := k.allowedFrom(origin.Source); err != nil {
+		return []error{errs.Wrap(err, "config-scope", "[aish] "+err.Error(), fields, warn)}
+	}
+	if k.Kind == KindPath && origin.Path != "" {
+		raw = expandHome(strings.TrimSpace(raw))
+		if raw != "" && !filepath.IsAbs(raw) {
+			raw = filepath.Join(filepath.Dir(origin.Path), raw)
+		}
+	}
+	if _, err := k.Parse(raw); err != nil {
+		value := errs.WithFields(map[string]string{"value": raw})
+		return []error{errs.Wrap(err, "config-invalid", fmt.Sprintf("[aish] invalid value for %s: %v", name, err), fields, value, warn)}
+	}
+	s.list[i].Raw = strings.TrimSpace(raw)
+	s.list[i].Origin = origin
+	return nil
+}
+
+func (k Key) allowedFrom(src Source) error {
+	switch {
+	case k.Scope == ScopeEnv && src != SourceEnv && src != SourceDefault:
+		return fmt.Errorf("%s can only be set through $%s", k.Name, k.Env)
+	case k.Scope == ScopeUser && src == SourceProject:
+		return fmt.Errorf("%s cannot be set in a project file", k.Name)
+	}
+	return nil
+}
+
+// fileValue is one leaf key read from a config file.
+type fileValue struct {
+	name string
+	raw  string
+	line int
+}
+
+// readFile returns the leaf keys of a config file and, separately, its profiles node.
+func readFile(path string) ([]fileValue, *yaml.Node, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, errs.Wrap(err, "config-read", "[aish] cannot read config file", errs.WithFields(map[string]string{"file": path}), errs.WithSeverity(errs.SeverityWarn))
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, nil, errs.Wrap(err, "config-parse", "[aish] invalid config file: "+err.Error(), errs.WithFields(map[string]string{"file": path}), errs.WithSeverity(errs.SeverityWarn))
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, errs.New("config-parse", "[aish] config file must be a mapping of keys", errs.WithFields(map[string]string{"file": path}), errs.WithSeverity(errs.SeverityWarn))
+	}
+
+	var out []fileValue
+	var profiles *yaml.Node
+	var walk func(n *yaml.Node, prefix string)
+	walk = func(n *yaml.Node, prefix string) {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, val := n.Content[i], n.Content[i+1]
+			name := key.Value
+			if prefix != "" {
+				name = prefix + "." + key.Value
+			}
+			if name == "profiles" {
+				profiles = val
+				continue
+			}
+			switch val.Kind {
+			case yaml.MappingNode:
+				walk(val, name)
+			case yaml.SequenceNode:
+				items := make([]string, 0, len(val.Content))
+				for _, item := range val.Content {
+					items = append(items, item.Value)
+				}
+				out = append(out, fileValue{name: name, raw: strings.Join(items, ","), line: key.Line})
+			default:
+				out = append(out, fileValue{name: name, raw: val.Value, line: key.Line})
+			}
+		}
+	}
+	walk(root, "")
+	return out, profiles, nil
+}
+
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, strings.TrimPrefix(p, "~"))
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mr-gaber/ai-shell/internal/errs"
+	"gopkg.in/yaml.v3"
+)
+
+// Profile is a named set of AI settings defined in the user config file and applied
+// automatically when the working directory or one of its git remotes matches:
+//
+//	profiles:
+//	  work:
+//	    paths: [~/src/acme-*]
+//	    remotes: ["*github.com*acme/*"]
+//	    model: gpt-4o
+//	    temperature: 0.2
+//	    prompt: Services here are written in Go 1.22 and deployed with Helm.
+//	    policy: ~/.aish/policy-work.yaml
+type Profile struct {
+	Name string
+	// Paths are globs matched against the working directory and each of its parents.
+	Paths []string
+	// Remotes are globs matched against the URLs of the repository's git remotes; * also matches "/".
+	Remotes []string
+	File    string
+	Line    int
+
+	values []fileValue
+}
+
+// profileFields maps the settings a profile may carry to their config keys.
+var profileFields = map[string]string{
+	"provider":    "ai.provider",
+	"model":       "ai.model",
+	"temperature": "ai.temperature",
+	"prompt":      "ai.prompt",
+	"policy":      "paths.policy",
+}
+
+// ProfileMatch records which profile was selected and why.
+type ProfileMatch struct {
+	Profile Profile
+	// By is "path" or "remote".
+	By      string
+	Pattern string
+	// Subject is the directory or remote URL the pattern matched.
+	Subject string
+}
+
+// Settings lists the config keys the profile sets, in file order.
+func (p Profile) Settings() []string {
+	out := make([]string, 0, len(p.values))
+	for _, v := range p.values {
+		out = append(out, v.name)
+	}
+	return out
+}
+
+func parseProfiles(path string, n *yaml.Node) ([]Profile, []error) {
+	warn := errs.WithSeverity(errs.SeverityWarn)
+	if n.Kind != yaml.MappingNode {
+		return nil, []error{errs.New("config-parse", fmt.Sprintf("[aish] %s:%d: profiles must be a mapping of names", path, n.Line), errs.WithFields(map[string]string{"file": path}), warn)}
+	}
+
+	var out []Profile
+	var problems []error
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		name, body := n.Content[i], n.Content[i+1]
+		p := Profile{Name: name.Value, File: path, Line: name.Line}
+		fields := errs.WithFields(map[string]string{"profile": p.Name, "file": path})
+		if body.Kind != yaml.MappingNode {
+			problems = append(problems, errs.New("config-parse", fmt.Sprintf("[aish] %s:%d: profile %q must be a mapping", path, name.Line, p.Name), fields, warn))
+			continue
+		}
+
+		for j := 0; j+1 < len(body.Content); j += 2 {
+			key, val := body.Content[j], body.Content[j+1]
+			switch key.Value {
+			case "paths", "remotes":
+				items := []string{val.Value}
+				if val.Kind == yaml.SequenceNode {
+					items = items[:0]
+					for _, item := range val.Content {
+						items = append(items, item.Value)
+					}
+				}
+				if key.Value == "paths" {
+					for _, item := range items {
+						p.Paths = append(p.Paths, expandHome(item))
+					}
+				} else {
+					p.Remotes = append(p.Remotes, items...)
+				}
+			default:
+				name, ok := profileFields[key.Value]
+				if !ok {
+					problems = append(problems, errs.New("config-unknown-key", fmt.Sprintf("[aish] %s:%d: unknown profile setting %q (use paths, remotes, provider, model, temperature, prompt or policy)", path, key.Line, key.Value), fields, warn))
+					continue
+				}
+				p.values = append(p.values, fileValue{name: name, raw: val.Value, line: key.Line})
+			}
+		}
+		if len(p.Paths) == 0 && len(p.Remotes) == 0 {
+			problems = append(problems, errs.New("config-invalid", fmt.Sprintf("[aish] %s:%d: profile %q has no paths or remotes and never applies", path, name.Line, p.Name), fields, warn))
+		}
+		out = append(out, p)
+	}
+	return out, problems
+}
+
+// SelectProfile returns the first profile matching dir, trying path globs before git remotes.
+func SelectProfile(profiles []Profile, dir string) (ProfileMatch, bool) {
+	if dir == "" {
+		return ProfileMatch{}, false
+	}
+	for _, p := range profiles {
+		for _, pattern := range p.Paths {
+			for d := dir; ; d = filepath.Dir(d) {
+				if ok, _ := filepath.Match(pattern, d); ok {
+					return ProfileMatch{Profile: p, By: "path", Pattern: pattern, Subject: d}, true
+				}
+				if filepath.Dir(d) == d {
+					break
+				}
+			}
+		}
+	}
+
+	var remotes []string
+	loaded := false
+	for _, p := range profiles {
+		if len(p.Remotes) == 0 {
+			continue
+		}
+		if !loaded {
+			remotes, loaded = gitRemotes(dir), true
+		}
+		for _, pattern := range p.Remotes {
+			for _, url := range remotes {
+				if globMatch(pattern, url) {
+					return ProfileMatch{Profile: p, By: "remote", Pattern: pattern, Subject: url}, true
+				}
+			}
+		}
+	}
+	return ProfileMatch{}, false
+}
+
+// gitRemotes lists the remote URLs of the repository containing dir, if any.
+func gitRemotes(dir string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "config", "--get-regexp", `^remote\..*\.url$`).Output()
+	if err != nil {
+		return nil
+	}
+	var urls []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if _, url, ok := strings.Cut(line, " "); ok {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// globMatch reports whether s matches pattern, where * matches any run of characters
+// (including "/") and ? matches one.
+func globMatch(pattern, s string) bool {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	return err == nil && re.MatchString(s)
+}
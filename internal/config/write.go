@@ -0,0 +1,110 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mr-gaber/ai-shell/internal/errs"
+	"gopkg.in/yaml.v3"
+)
+
+// Set validates raw for the named key and writes it into the config file at path, keeping the
+// file's other keys and comments. source says whether path is the user or a project file.
+func Set(path string, source Source, name, raw string) error {
+	fields := map[string]string{"key": name, "file": path}
+	k, ok := Lookup(name)
+	if !ok {
+		return errs.New("config-unknown-key", fmt.Sprintf("[aish] unknown config key %q", name), errs.WithFields(fields))
+	}
+	if err := k.allowedFrom(source); err != nil {
+		return errs.Wrap(err, "config-scope", "[aish] "+err.Error(), errs.WithFields(fields))
+	}
+	if _, err := k.Parse(raw); err != nil {
+		fields["value"] = raw
+		return errs.Wrap(err, "config-invalid", fmt.Sprintf("[aish] invalid value for %s: %v", name, err), errs.WithFields(fields))
+	}
+
+	var doc yaml.Node
+	b, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errs.Wrap(err, "config-read", "[aish] cannot read config file", errs.WithFields(fields))
+	}
+	if len(bytes.TrimSpace(b)) > 0 {
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return errs.Wrap(err, "config-parse", "[aish] invalid config file: "+err.Error(), errs.WithFields(fields))
+		}
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return errs.New("config-parse", "[aish] config file must be a mapping of keys", errs.WithFields(fields))
+	}
+
+	setNode(root, strings.Split(name, "."), valueNode(k, raw))
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return errs.Wrap(err, "config-write", "[aish] cannot encode config file", errs.WithFields(fields))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return errs.Wrap(err, "config-write", "[aish] cannot create config directory", errs.WithFields(fields))
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0o600); err != nil {
+		return errs.Wrap(err, "config-write", "[aish] cannot write config file", errs.WithFields(fields))
+	}
+	return nil
+}
+
+// setNode stores value under the dotted path, creating intermediate mappings as needed.
+func setNode(m *yaml.Node, path []string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			m.Content[i+1] = value
+			return
+		}
+		if m.Content[i+1].Kind != yaml.MappingNode {
+			m.Content[i+1] = &yaml.Node{Kind: yaml.MappingNode}
+		}
+		setNode(m.Content[i+1], path[1:], value)
+		return
+	}
+
+	key := &yaml.Node{Kind: yaml.ScalarNode, Value: path[0]}
+	if len(path) == 1 {
+		m.Content = append(m.Content, key, value)
+		return
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode}
+	m.Content = append(m.Content, key, child)
+	setNode(child, path[1:], value)
+}
+
+func valueNode(k Key, raw string) *yaml.Node {
+	raw = strings.TrimSpace(raw)
+	switch k.Kind {
+	case KindInt, KindFloat, KindBool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: raw}
+	case KindString, KindPath:
+		n := &yaml.Node{}
+		_ = n.Encode(raw)
+		return n
+	}
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: item})
+		}
+	}
+	return seq
+}
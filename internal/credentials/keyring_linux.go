@@ -0,0 +1,47 @@
+//go:build linux
+
+package credentials
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// keyringPrefix namespaces aish entries in the user keyring.
+const keyringPrefix = "aish:"
+
+// cacheGet reads an unlocked credential from the user's kernel keyring.
+func cacheGet(name string) (string, bool) {
+	id, err := unix.KeyctlSearch(unix.KEY_SPEC_USER_KEYRING, "user", keyringPrefix+name, 0)
+	if err != nil {
+		return "", false
+	}
+	buf := make([]byte, 4096)
+	n, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, buf, 0)
+	if err != nil || n > len(buf) {
+		return "", false
+	}
+	return string(buf[:n]), true
+}
+
+// cachePut keeps an unlocked credential in the user's kernel keyring until ttl expires,
+// so later invocations, including background hints, need no passphrase.
+func cachePut(name, value string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	id, err := unix.AddKey("user", keyringPrefix+name, []byte(value), unix.KEY_SPEC_USER_KEYRING)
+	if err != nil {
+		return err
+	}
+	_, err = unix.KeyctlInt(unix.KEYCTL_SET_TIMEOUT, id, int(ttl/time.Second), 0, 0)
+	return err
+}
+
+// cacheDrop removes a cached credential.
+func cacheDrop(name string) {
+	if id, err := unix.KeyctlSearch(unix.KEY_SPEC_USER_KEYRING, "user", keyringPrefix+name, 0); err == nil {
+		_, _ = unix.KeyctlInt(unix.KEYCTL_INVALIDATE, id, 0, 0, 0)
+	}
+}
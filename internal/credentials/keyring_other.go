@@ -0,0 +1,11 @@
+//go:build !linux
+
+package credentials
+
+import "time"
+
+func cacheGet(string) (string, bool) { return "", false }
+
+func cachePut(string, string, time.Duration) error { return nil }
+
+func cacheDrop(string) {}
@@ -0,0 +1,100 @@
+package credentials
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mr-gaber/ai-shell/internal/config"
+	"github.com/mr-gaber/ai-shell/internal/errs"
+	"github.com/mr-gaber/ai-shell/internal/shell/control"
+	"golang.org/x/term"
+)
+
+// Lookup finds the credential name without it ever being in the environment. It asks the aish
+// session over its control socket, then the kernel keyring cache, then unlocks the credentials
+// file. The passphrase is only prompted for when interactive is set; otherwise a locked file
+// yields a credentials-locked error. An empty value with a nil error means the key is not stored.
+func Lookup(cfg config.Config, name string, interactive bool) (string, error) {
+	if cfg.Paths.ControlSock != "" {
+		if v, err := control.Fetch(cfg.Paths.ControlSock, control.Request{Op: control.OpCredential, Text: name}); err == nil && v != "" {
+			return v, nil
+		}
+	}
+	if v, ok := cacheGet(name); ok {
+		return v, nil
+	}
+
+	store := NewStore(cfg.Paths.CredentialsFile)
+	if !store.Contains(name) {
+		return "", nil
+	}
+	if !interactive {
+		return "", errs.New("credentials-locked", fmt.Sprintf("[aish] %s is stored encrypted; run an ai command interactively to unlock it", name), errs.WithFields(map[string]string{"key": name}))
+	}
+
+	passphrase, err := ReadPassphrase(fmt.Sprintf("[aish] Passphrase for %s: ", store.Path()))
+	if err != nil {
+		return "", err
+	}
+	values, err := store.Load(passphrase)
+	if err != nil {
+		return "", err
+	}
+	for n, v := range values {
+		Remember(n, v, cfg.Credentials.CacheTTL)
+	}
+	return values[name], nil
+}
+
+// Fill sets the API key the configured provider needs when it is not in the environment.
+func Fill(cfg *config.Config, interactive bool) error {
+	if cfg.AIProvider != "openai" || cfg.OpenAIKey != "" {
+		return nil
+	}
+	key, err := Lookup(*cfg, "openai.api_key", interactive)
+	if err != nil {
+		return err
+	}
+	cfg.OpenAIKey = key
+	return nil
+}
+
+// Cached reports whether name is currently unlocked in the kernel keyring.
+func Cached(name string) bool {
+	_, ok := cacheGet(name)
+	return ok
+}
+
+// Lock drops name from the kernel keyring cache.
+func Lock(name string) {
+	cacheDrop(name)
+}
+
+// Remember caches an unlocked value in the kernel keyring for ttl; zero, the default, caches
+// nothing. Any process running as the user can read the keyring. It is a convenience: failing
+// to cache only means asking for the passphrase again next time.
+func Remember(name, value string, ttl time.Duration) {
+	if ttl > 0 {
+		_ = cachePut(name, value, ttl)
+	}
+}
+
+// ReadPassphrase prompts on the controlling terminal and reads a line without echo, so it
+// works even when stdin and stdout are redirected.
+func ReadPassphrase(prompt string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", errs.Wrap(err, "credentials-no-tty", "[aish] a terminal is required to enter the passphrase")
+	}
+	defer tty.Close()
+
+	fmt.Fprint(tty, prompt)
+	b, err := term.ReadPassword(int(tty.Fd()))
+	fmt.Fprintln(tty)
+	if err != nil {
+		return "", errs.Wrap(err, "credentials-no-tty", "[aish] cannot read the passphrase")
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
@@ -0,0 +1,208 @@
+// Package credentials keeps API keys out of the environment: in an encrypted file on disk,
+// cached in the kernel keyring after unlock, or served by the aish session over its control socket.
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/mr-gaber/ai-shell/internal/errs"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// fileVersion 2 authenticates the header; version 1 files are not read.
+	fileVersion = 2
+	kdfName     = "pbkdf2-sha256"
+	// kdfIterations follows current OWASP guidance for PBKDF2-HMAC-SHA256.
+	kdfIterations = 600_000
+	saltSize      = 16
+	keySize       = 32
+)
+
+// envelope is the on-disk format. Names is plaintext metadata so status works while locked;
+// it is authenticated together with the KDF parameters, so tampering with it makes Load fail.
+type envelope struct {
+	Version    int      `json:"version"`
+	KDF        string   `json:"kdf"`
+	Iterations int      `json:"iterations"`
+	Salt       []byte   `json:"salt"`
+	Nonce      []byte   `json:"nonce"`
+	Data       []byte   `json:"data"`
+	Names      []string `json:"names"`
+}
+
+// Store is an AES-256-GCM encrypted credentials file, readable only by its owner.
+type Store struct {
+	path string
+}
+
+// NewStore returns the store at path. The file is created on first Save.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the location of the credentials file.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Exists reports whether the credentials file has been created.
+func (s *Store) Exists() bool {
+	_, err := os.Stat(s.path)
+	return err == nil
+}
+
+// Names lists stored credential names without decrypting the file.
+func (s *Store) Names() ([]string, error) {
+	env, err := s.read()
+	if err != nil || env == nil {
+		return nil, err
+	}
+	return env.Names, nil
+}
+
+// Load decrypts every stored credential with passphrase. A missing file yields an empty map.
+func (s *Store) Load(passphrase string) (map[string]string, error) {
+	env, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	if env == nil {
+		return map[string]string{}, nil
+	}
+	if env.Version != fileVersion || env.KDF != kdfName {
+		return nil, errs.New("credentials-format", fmt.Sprintf("[aish] unsupported credentials file (version %d, kdf %q)", env.Version, env.KDF), errs.WithFields(map[string]string{"file": s.path}))
+	}
+
+	gcm, err := newGCM(passphrase, env.Salt, env.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, env.Nonce, env.Data, env.additionalData())
+	if err != nil {
+		return nil, errs.New("credentials-decrypt", "[aish] wrong passphrase or corrupted credentials file", errs.WithFields(map[string]string{"file": s.path}))
+	}
+	values := map[string]string{}
+	if err := json.Unmarshal(plain, &values); err != nil {
+		return nil, errs.Wrap(err, "credentials-format", "[aish] corrupted credentials file", errs.WithFields(map[string]string{"file": s.path}))
+	}
+	return values, nil
+}
+
+// Save encrypts values with passphrase under a fresh salt and nonce and replaces the file atomically.
+func (s *Store) Save(passphrase string, values map[string]string) error {
+	plain, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	gcm, err := newGCM(passphrase, salt, kdfIterations)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	env := envelope{
+		Version:    fileVersion,
+		KDF:        kdfName,
+		Iterations: kdfIterations,
+		Salt:       salt,
+		Nonce:      nonce,
+		Names:      names,
+	}
+	env.Data = gcm.Seal(nil, nonce, plain, env.additionalData())
+	b, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return errs.Wrap(err, "credentials-write", "[aish] cannot create credentials directory", errs.WithFields(map[string]string{"file": s.path}))
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".credentials-*")
+	if err != nil {
+		return errs.Wrap(err, "credentials-write", "[aish] cannot write credentials file", errs.WithFields(map[string]string{"file": s.path}))
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return errs.Wrap(err, "credentials-write", "[aish] cannot write credentials file", errs.WithFields(map[string]string{"file": s.path}))
+	}
+	if err := tmp.Close(); err != nil {
+		return errs.Wrap(err, "credentials-write", "[aish] cannot write credentials file", errs.WithFields(map[string]string{"file": s.path}))
+	}
+	// CreateTemp already uses 0600; rename keeps it.
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return errs.Wrap(err, "credentials-write", "[aish] cannot write credentials file", errs.WithFields(map[string]string{"file": s.path}))
+	}
+	return nil
+}
+
+// Contains reports whether name is listed in the file header.
+func (s *Store) Contains(name string) bool {
+	names, err := s.Names()
+	return err == nil && slices.Contains(names, name)
+}
+
+func (s *Store) read() (*envelope, error) {
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errs.Wrap(err, "credentials-read", "[aish] cannot read credentials file", errs.WithFields(map[string]string{"file": s.path}))
+	}
+	if info.Mode().Perm()&0o077 != 0 {
+		return nil, errs.New("credentials-permissions", fmt.Sprintf("[aish] credentials file is accessible by other users (mode %04o); run chmod 600", info.Mode().Perm()), errs.WithFields(map[string]string{"file": s.path}))
+	}
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, errs.Wrap(err, "credentials-read", "[aish] cannot read credentials file", errs.WithFields(map[string]string{"file": s.path}))
+	}
+	var env envelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		return nil, errs.Wrap(err, "credentials-format", "[aish] corrupted credentials file", errs.WithFields(map[string]string{"file": s.path}))
+	}
+	return &env, nil
+}
+
+// additionalData is the plaintext header bound to the ciphertext as GCM additional data.
+func (e envelope) additionalData() []byte {
+	b, _ := json.Marshal(struct {
+		Version    int      `json:"version"`
+		KDF        string   `json:"kdf"`
+		Iterations int      `json:"iterations"`
+		Names      []string `json:"names"`
+	}{e.Version, e.KDF, e.Iterations, e.Names})
+	return b
+}
+
+func newGCM(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, iterations, keySize, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -2,8 +2,19 @@ package builtins
 
 import "strings"
 
+// persisting lists builtins whose effects are lost when run in a child shell.
+var persisting = map[string]bool{
+	"cd": true, "pushd": true, "popd": true,
+	"export": true, "unset": true,
+	"alias": true, "unalias": true,
+	"source": true, ".": true,
+	"umask": true,
+}
+
 // IsNonPersisting reports whether the command is a shell builtin whose effects won’t persist.
 func IsNonPersisting(cmd string) bool {
 	s := strings.TrimSpace(cmd)
-	return strings.HasPrefix(s, "cd ") || strings.HasPrefix(s, "export ") || s == "cd" || strings.HasPrefix(s, "alias ")
+	first, _, _ := strings.Cut(s, " ")
+	first = strings.TrimRight(first, ";&|")
+	return persisting[first]
 }
@@ -7,17 +7,23 @@ import (
 	"github.com/mr-gaber/ai-shell/internal/config"
 	"github.com/mr-gaber/ai-shell/internal/session/history"
 	"github.com/mr-gaber/ai-shell/internal/session/logs"
+	"github.com/mr-gaber/ai-shell/internal/session/project"
 	"github.com/mr-gaber/ai-shell/internal/session/redact"
 	"github.com/mr-gaber/ai-shell/internal/utils"
 )
 
 type Builder struct {
-	history history.Reader
-	logs    logs.Reader
-	limits  config.Limits
+	history   history.Reader
+	logs      logs.Reader
+	limits    config.Limits
+	redactor  *redact.Redactor
+	redactErr error
+	secrets   *redact.Vault
+	providers []project.Provider
 }
 
 func NewBuilder(cfg config.Config) Builder {
+	redactor, redactErr := redact.Load(cfg.Paths.RedactFile)
 	return Builder{
 		history: history.Reader{
 			Path:     cfg.Paths.HistoryFile,
@@ -29,34 +35,37 @@ func NewBuilder(cfg config.Config) Builder {
 			Lines:    cfg.Limits.TailLines,
 			MaxBytes: int64(cfg.Limits.TailMaxBytes),
 		},
-		limits: cfg.Limits,
+		limits:    cfg.Limits,
+		redactor:  redactor,
+		redactErr: redactErr,
+		secrets:   redact.NewVault(),
+		providers: project.Defaults(),
 	}
 }
 
-type histEntry struct {
-	TS   string `json:"ts"`
-	CWD  string `json:"cwd"`
-	Cmd  string `json:"cmd"`
-	Exit int    `json:"exit"`
-	Git  string `json:"git"`
+// Secrets returns the local mapping for placeholders emitted by Build.
+func (b Builder) Secrets() *redact.Vault {
+	return b.secrets
 }
 
 func (b Builder) Build() (string, bool, error) {
+	if b.redactErr != nil {
+		return "", false, b.redactErr
+	}
+
 	historyLines, err := b.history.Read()
 	if err != nil {
 		return "", false, err
 	}
 
-	historyEntries := utils.ParseJSONL(historyLines, func(e histEntry) bool { return e.Cmd != "" })
-
-	lastCmd, lastExit, isError := getLastCmdAndExit(historyEntries)
-	if looksLikeFailure(lastCmd) {
-		isError = true
-	}
+	historyEntries := utils.ParseJSONL(historyLines, func(e history.Entry) bool { return e.Cmd != "" })
 
-	if lastCmd == "" || lastExit == -1 {
+	last, found := lastNonHelper(historyEntries)
+	if !found {
 		return "", false, fmt.Errorf("no recent non-helper command found in history")
 	}
+	lastCmd, lastExit := last.Cmd, last.Exit
+	isError := lastExit != 0
 
 	recentCmds := make([]string, 0, b.limits.TailLines)
 	for i := len(historyEntries) - 1; i >= 0 && len(recentCmds) < b.limits.TailLines; i-- {
@@ -71,33 +80,67 @@ func (b Builder) Build() (string, bool, error) {
 	}
 	logsStr := strings.Join(logLines, "\n")
 
+	failure := Classify(outputOf(logLines, lastCmd))
+	if failure.Category != CategoryNone {
+		isError = true
+	}
+
 	block := fmt.Sprintf(`Recent commands (most recent first): %s
 Last command: %s
 Exit code: %d
-Session logs (last %d lines):
+%s%sSession logs (last %d lines):
 %s
-`, recentCmdsStr, lastCmd, lastExit, b.limits.TailLines, logsStr)
+`, recentCmdsStr, lastCmd, lastExit, failureSection(failure), b.projectSection(last.CWD), b.limits.TailLines, logsStr)
+
+	return b.redactor.ScrubReversible(block, b.secrets), isError, nil
+}
+
+// LastCommand returns the most recent non-helper command from the session history.
+func (b Builder) LastCommand() (history.Entry, error) {
+	historyLines, err := b.history.Read()
+	if err != nil {
+		return history.Entry{}, err
+	}
+	entries := utils.ParseJSONL(historyLines, func(e history.Entry) bool { return e.Cmd != "" })
+	if last, found := lastNonHelper(entries); found {
+		return last, nil
+	}
+	return history.Entry{}, fmt.Errorf("no recent non-helper command found in history")
+}
 
-	return redact.Scrub(block), isError, nil
+// Scrub redacts s with the same placeholders used by Build, so follow-up context stays consistent.
+func (b Builder) Scrub(s string) string {
+	return b.redactor.ScrubReversible(s, b.secrets)
 }
 
-func getLastCmdAndExit(history []histEntry) (string, int, bool) {
-	var lastCmd string
-	lastExit := -1
-	isError := false
-
-	for i := len(history) - 1; i >= 0; i-- {
-		if !isHelper(history[i].Cmd) {
-			lastCmd = history[i].Cmd
-			lastExit = history[i].Exit
-			isError = lastExit != 0
-			break
+func lastNonHelper(entries []history.Entry) (history.Entry, bool) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !history.IsHelper(entries[i].Cmd) {
+			return entries[i], true
 		}
 	}
-	return lastCmd, lastExit, isError
+	return history.Entry{}, false
 }
 
-func isHelper(cmd string) bool {
-	t := strings.TrimSpace(cmd)
-	return strings.HasPrefix(t, "ai ") || strings.HasPrefix(t, "snip ")
+func failureSection(f Failure) string {
+	if f.Category == CategoryNone {
+		return ""
+	}
+	return fmt.Sprintf("Failure category: %s\nKey lines:\n%s\n", f.Category, strings.Join(f.KeyLines, "\n"))
+}
+
+// projectSection describes the project in the directory the last command ran in.
+// The whole block is scrubbed by Build, so provider output never leaves unredacted.
+func (b Builder) projectSection(dir string) string {
+	sections := project.Collect(dir, b.providers, b.limits.ProjectBudget)
+	if len(sections) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Project context (%s):\n", dir)
+	for _, s := range sections {
+		fmt.Fprintf(&sb, "[%s]\n%s\n", s.Name, s.Body)
+	}
+	return sb.String()
 }
@@ -1,22 +1,152 @@
 package context
 
-import "strings"
-
-func looksLikeFailure(s string) bool {
-	t := strings.ToLower(s)
-	keywords := []string{
-		"error", "failed", "fatal", "exception", "traceback",
-		"not found", "no such file or directory", "permission denied",
-		"invalid operation", "cannot", "unrecognized", "unknown command",
+import (
+	"regexp"
+	"strings"
+)
+
+// Category names a class of command failure recognised from its output.
+type Category string
+
+const (
+	CategoryNone             Category = ""
+	CategoryPythonTraceback  Category = "python-traceback"
+	CategoryCompileError     Category = "compile-error"
+	CategoryTestFailure      Category = "test-failure"
+	CategoryPackageManager   Category = "package-manager"
+	CategoryMissingBinary    Category = "missing-binary"
+	CategoryPermissionDenied Category = "permission-denied"
+	CategoryNetwork          Category = "network"
+	CategoryMissingFile      Category = "missing-file"
+)
+
+// Failure is the classification of a command's output.
+type Failure struct {
+	Category Category
+	KeyLines []string
+}
+
+// maxKeyLines caps how many matching lines are quoted back in the prompt.
+const maxKeyLines = 8
+
+type failureRule struct {
+	category Category
+	patterns []*regexp.Regexp
+}
+
+// failureRules are ordered by specificity; the first category with a match wins.
+var failureRules = []failureRule{
+	{CategoryPythonTraceback, compile(
+		`^Traceback \(most recent call last\):`,
+		`^\s*File ".+", line \d+`,
+		`^[A-Za-z_][A-Za-z0-9_.]*(Error|Exception|Exit|Interrupt): `,
+	)},
+	{CategoryCompileError, compile(
+		`^\S+\.(go|c|cc|cpp|h|hpp|rs|ts|tsx|java|kt|swift|cs):\d+(:\d+)?:`,
+		`error\[E\d+\]`,
+		`error TS\d+:`,
+		`undefined reference to`,
+		`cannot find symbol`,
+	)},
+	{CategoryTestFailure, compile(
+		`^--- FAIL:`,
+		`^FAIL\s`,
+		`^FAILED `,
+		`\b\d+ (failed|failing)\b`,
+		`^Tests:\s+.*\d+ failed`,
+		`AssertionError`,
+	)},
+	{CategoryPackageManager, compile(
+		`^E: `,
+		`^npm ERR!`,
+		`Unable to locate package`,
+		`No matching distribution found`,
+		`^ERROR: Could not find a version`,
+		`^error: target not found:`,
+		`^No match for argument:`,
+	)},
+	{CategoryMissingBinary, compile(
+		`command not found`,
+		`: not found$`,
+		`executable file not found in \$PATH`,
+	)},
+	{CategoryPermissionDenied, compile(
+		`(?i)permission denied`,
+		`(?i)operation not permitted`,
+		`\bEACCES\b`,
+		`(?i)must be run as root`,
+		`(?i)are you root\?`,
+	)},
+	{CategoryNetwork, compile(
+		`(?i)could not resolve host`,
+		`(?i)temporary failure in name resolution`,
+		`(?i)connection (refused|timed out|reset)`,
+		`(?i)network is unreachable`,
+		`(?i)tls handshake timeout`,
+		`(?i)i/o timeout`,
+		`\b(ECONNREFUSED|ENOTFOUND|ETIMEDOUT)\b`,
+	)},
+	{CategoryMissingFile, compile(
+		`(?i)no such file or directory`,
+		`\bENOENT\b`,
+	)},
+}
+
+func compile(patterns ...string) []*regexp.Regexp {
+	out := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		out[i] = regexp.MustCompile(p)
 	}
-	for _, kw := range keywords {
-		if strings.Contains(t, kw) {
-			return true
+	return out
+}
+
+// Classify inspects command output and returns the most specific failure category found,
+// together with the lines that triggered it.
+func Classify(lines []string) Failure {
+	for _, rule := range failureRules {
+		var key []string
+		for _, ln := range lines {
+			ln = strings.TrimRight(ln, " \t")
+			for _, re := range rule.patterns {
+				if re.MatchString(ln) {
+					key = append(key, ln)
+					break
+				}
+			}
+		}
+		if len(key) == 0 {
+			continue
+		}
+		// The last lines usually carry the actual error (e.g. the exception after a traceback).
+		if len(key) > maxKeyLines {
+			key = key[len(key)-maxKeyLines:]
 		}
+		return Failure{Category: rule.category, KeyLines: key}
 	}
+	return Failure{}
+}
+
+var ansiRE = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)|\x1b[()][0-9A-Za-z]`)
 
-	if strings.Contains(s, "\nE:") || strings.HasPrefix(s, "E:") {
-		return true
+// stripANSI removes terminal escape sequences recorded in the session log.
+func stripANSI(s string) string {
+	return strings.TrimRight(ansiRE.ReplaceAllString(s, ""), "\r")
+}
+
+// outputOf returns the log lines printed after the last echo of cmd, or all lines if it is not found.
+func outputOf(lines []string, cmd string) []string {
+	cmd = strings.TrimSpace(cmd)
+	clean := make([]string, len(lines))
+	for i, ln := range lines {
+		clean[i] = stripANSI(ln)
+	}
+	if cmd == "" {
+		return clean
+	}
+	for i := len(clean) - 1; i >= 0; i-- {
+		if strings.HasSuffix(strings.TrimSpace(clean[i]), cmd) {
+			return clean[i+1:]
+		}
 	}
-	return false
+	return clean
 }
@@ -0,0 +1,552 @@
+package danger
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Severity ranks how destructive a command is considered.
+type Severity int
+
+const (
+	SeverityNone Severity = iota
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+// String returns the lowercase name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityLow:
+		return "low"
+	case SeverityMedium:
+		return "medium"
+	case SeverityHigh:
+		return "high"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return "none"
+	}
+}
+
+// Reason explains a single finding of the analyser.
+type Reason struct {
+	Severity Severity
+	Command  string
+	Message  string
+}
+
+// String renders the reason for display.
+func (r Reason) String() string {
+	if r.Command == "" {
+		return fmt.Sprintf("[%s] %s", r.Severity, r.Message)
+	}
+	return fmt.Sprintf("[%s] %s: %s", r.Severity, r.Command, r.Message)
+}
+
+// Verdict is the structured result of analysing a command line.
+type Verdict struct {
+	Severity Severity
+	Reasons  []Reason
+}
+
+// Dangerous reports whether the verdict should block automatic execution.
+func (v Verdict) Dangerous() bool {
+	return v.Severity >= SeverityHigh
+}
+
+func (v *Verdict) add(sev Severity, cmd simpleCommand, format string, args ...any) {
+	v.Reasons = append(v.Reasons, Reason{Severity: sev, Command: cmd.String(), Message: fmt.Sprintf(format, args...)})
+	if sev > v.Severity {
+		v.Severity = sev
+	}
+}
+
+func (v *Verdict) merge(other Verdict) {
+	v.Reasons = append(v.Reasons, other.Reasons...)
+	if other.Severity > v.Severity {
+		v.Severity = other.Severity
+	}
+}
+
+var forkBombRE = regexp.MustCompile(`([A-Za-z_:][A-Za-z0-9_:]*)\s*\(\)\s*\{\s*([A-Za-z_:][A-Za-z0-9_:]*)\s*\|\s*([A-Za-z_:][A-Za-z0-9_:]*)\s*&\s*\}`)
+
+// isForkBomb matches functions that pipe themselves into themselves in the background.
+func isForkBomb(s string) bool {
+	for _, m := range forkBombRE.FindAllStringSubmatch(s, -1) {
+		if m[1] == m[2] && m[2] == m[3] {
+			return true
+		}
+	}
+	return false
+}
+
+// Analyze tokenises cmd like a shell would, resolves wrappers such as sudo, env and xargs,
+// and checks every resulting command against known destructive patterns.
+func Analyze(cmd string) Verdict {
+	var v Verdict
+	s := strings.TrimSpace(cmd)
+	if s == "" {
+		v.add(SeverityHigh, simpleCommand{}, "empty command")
+		return v
+	}
+	if strings.Contains(s, "\n") {
+		v.add(SeverityHigh, simpleCommand{}, "multi-line commands are not run automatically")
+	}
+	if isForkBomb(s) {
+		v.add(SeverityCritical, simpleCommand{}, "fork bomb")
+	}
+
+	v.merge(analyze(s, 0))
+	return v
+}
+
+const maxDepth = 4
+
+func analyze(s string, depth int) Verdict {
+	var v Verdict
+	if depth > maxDepth {
+		v.add(SeverityHigh, simpleCommand{}, "too many nested shells to analyse")
+		return v
+	}
+
+	tokens, subs, err := lex(s)
+	if err != nil {
+		v.add(SeverityHigh, simpleCommand{}, "cannot parse command: %v", err)
+		return v
+	}
+	for _, sub := range subs {
+		v.merge(analyze(sub, depth+1))
+	}
+
+	for _, p := range parse(tokens) {
+		for i, c := range p {
+			checkRedirects(&v, c)
+			if c.name() == "" {
+				continue
+			}
+			if i > 0 && slices.Contains(c.wrappers, "xargs") {
+				c = withPipedArgs(c, p[i-1])
+			}
+			if check, ok := checks[c.name()]; ok {
+				check(&v, c)
+			}
+			if strings.HasPrefix(c.name(), "mkfs") {
+				v.add(SeverityCritical, c, "creates a filesystem, destroying existing data")
+			}
+			if isShell(c.name()) {
+				if script, ok := inlineScript(c); ok {
+					v.merge(analyze(script, depth+1))
+				} else if i > 0 && isDownloader(p[i-1].name()) {
+					v.add(SeverityHigh, c, "executes a script downloaded by %s", p[i-1].name())
+				}
+			}
+		}
+	}
+	return v
+}
+
+// withPipedArgs appends what an echo or printf feeding xargs prints, so `echo / | xargs rm -rf`
+// is checked as `rm -rf /`. Other producers are opaque and leave c unchanged.
+func withPipedArgs(c, producer simpleCommand) simpleCommand {
+	switch producer.name() {
+	case "echo", "printf":
+	default:
+		return c
+	}
+	var words []string
+	for _, a := range producer.args() {
+		if strings.HasPrefix(a, "-") {
+			continue
+		}
+		words = append(words, strings.Fields(strings.ReplaceAll(a, `\n`, " "))...)
+	}
+	c.argv = append(append([]string{}, c.argv...), words...)
+	c.piped = true
+	return c
+}
+
+type check func(v *Verdict, c simpleCommand)
+
+var checks = map[string]check{
+	"rm":       checkRm,
+	"dd":       checkDd,
+	"shred":    checkShred,
+	"chmod":    checkRecursiveOwnership,
+	"chown":    checkRecursiveOwnership,
+	"chgrp":    checkRecursiveOwnership,
+	"find":     checkFind,
+	"docker":   checkDocker,
+	"git":      checkGit,
+	"mkswap":   checkDisk,
+	"wipefs":   checkDisk,
+	"fdisk":    checkDisk,
+	"sfdisk":   checkDisk,
+	"parted":   checkDisk,
+	"shutdown": checkPower,
+	"reboot":   checkPower,
+	"halt":     checkPower,
+	"poweroff": checkPower,
+	"kill":     checkKill,
+	"pkill":    checkPkill,
+	"killall":  checkKillall,
+	"killall5": checkKillall,
+}
+
+func checkRm(v *Verdict, c simpleCommand) {
+	flags, targets := splitFlags(c.args())
+	recursive := hasFlag(flags, 'r', "--recursive") || hasFlag(flags, 'R', "")
+	force := hasFlag(flags, 'f', "--force")
+	if slices.Contains(flags, "--no-preserve-root") {
+		v.add(SeverityCritical, c, "disables the root filesystem safeguard")
+	}
+	if !recursive {
+		return
+	}
+	if slices.Contains(c.wrappers, "xargs") && !c.piped {
+		v.add(SeverityMedium, c, "recursively deletes paths read from standard input")
+	}
+	for _, t := range targets {
+		switch {
+		case isRootOrHome(t):
+			v.add(SeverityCritical, c, "recursively deletes %s", t)
+		case isSystemPath(t):
+			v.add(SeverityHigh, c, "recursively deletes system path %s", t)
+		case force && (t == "*" || t == "." || t == ".."):
+			v.add(SeverityMedium, c, "force-deletes everything matching %s in the current directory", t)
+		}
+	}
+}
+
+func checkDd(v *Verdict, c simpleCommand) {
+	for _, a := range c.args() {
+		if strings.HasPrefix(a, "of=/dev/") && !isHarmlessDevice(strings.TrimPrefix(a, "of=")) {
+			v.add(SeverityCritical, c, "writes raw data to device %s", strings.TrimPrefix(a, "of="))
+			return
+		}
+	}
+	v.add(SeverityLow, c, "dd copies raw data; double-check if= and of=")
+}
+
+func checkShred(v *Verdict, c simpleCommand) {
+	_, targets := splitFlags(c.args())
+	for _, t := range targets {
+		if strings.HasPrefix(t, "/dev/") {
+			v.add(SeverityCritical, c, "overwrites device %s", t)
+			return
+		}
+	}
+	v.add(SeverityMedium, c, "irreversibly overwrites files")
+}
+
+func checkRecursiveOwnership(v *Verdict, c simpleCommand) {
+	flags, targets := splitFlags(c.args())
+	if !hasFlag(flags, 'R', "--recursive") {
+		return
+	}
+	for _, t := range targets {
+		if isRootOrHome(t) || isSystemPath(t) {
+			v.add(SeverityHigh, c, "recursively changes permissions/ownership of %s", t)
+		}
+	}
+}
+
+func checkFind(v *Verdict, c simpleCommand) {
+	args := c.args()
+	var roots []string
+	for _, a := range args {
+		if strings.HasPrefix(a, "-") || a == "(" || a == "!" {
+			break
+		}
+		roots = append(roots, a)
+	}
+
+	deletes := slices.Contains(args, "-delete")
+	for i, a := range args {
+		if (a == "-exec" || a == "-execdir" || a == "-ok") && i+1 < len(args) {
+			switch path.Base(args[i+1]) {
+			case "rm", "shred", "unlink":
+				deletes = true
+			}
+		}
+	}
+	if !deletes {
+		return
+	}
+	for _, r := range roots {
+		switch {
+		case isRootOrHome(r):
+			v.add(SeverityCritical, c, "deletes files found under %s", r)
+		case isSystemPath(r):
+			v.add(SeverityHigh, c, "deletes files found under system path %s", r)
+		}
+	}
+	if len(roots) == 0 {
+		v.add(SeverityLow, c, "deletes files found under the current directory")
+	}
+}
+
+func checkDocker(v *Verdict, c simpleCommand) {
+	args := c.args()
+	if len(args) >= 2 && args[0] == "system" && args[1] == "prune" {
+		flags, _ := splitFlags(args[2:])
+		if hasFlag(flags, 'a', "--all") || slices.Contains(flags, "--volumes") {
+			v.add(SeverityHigh, c, "removes all unused images, containers and networks")
+			return
+		}
+		v.add(SeverityLow, c, "removes stopped containers and dangling images")
+	}
+}
+
+func checkGit(v *Verdict, c simpleCommand) {
+	args := c.args()
+	if len(args) == 0 {
+		return
+	}
+	flags, _ := splitFlags(args[1:])
+	switch args[0] {
+	case "push":
+		if hasFlag(flags, 'f', "--force") || slices.Contains(flags, "--force-with-lease") {
+			v.add(SeverityMedium, c, "rewrites remote history")
+		}
+	case "reset":
+		if slices.Contains(flags, "--hard") {
+			v.add(SeverityMedium, c, "discards uncommitted changes")
+		}
+	case "clean":
+		if hasFlag(flags, 'f', "--force") {
+			v.add(SeverityMedium, c, "deletes untracked files")
+		}
+	}
+}
+
+func checkDisk(v *Verdict, c simpleCommand) {
+	v.add(SeverityCritical, c, "modifies disk partitions or signatures")
+}
+
+func checkPower(v *Verdict, c simpleCommand) {
+	v.add(SeverityHigh, c, "shuts down or restarts the machine")
+}
+
+func checkKill(v *Verdict, c simpleCommand) {
+	for _, a := range c.args() {
+		switch a {
+		case "-1":
+			v.add(SeverityHigh, c, "signals every process you own")
+			return
+		case "1":
+			v.add(SeverityHigh, c, "signals init")
+			return
+		}
+	}
+}
+
+// pkillValueFlags take a value, so the next argument is not a pattern.
+var pkillValueFlags = []string{"-g", "-G", "-P", "-s", "-t", "-F", "--signal", "--pgroup", "--group", "--parent", "--session", "--terminal", "--pidfile", "--ns", "--nslist"}
+
+// matchesAll reports whether a pgrep/killall regular expression matches every process name.
+func matchesAll(pattern string) bool {
+	switch strings.Trim(pattern, "^$") {
+	case "", ".", ".*", ".+":
+		return true
+	}
+	return false
+}
+
+func checkPkill(v *Verdict, c simpleCommand) {
+	args := c.args()
+	user := false
+	var patterns []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "-u" || a == "-U" || a == "--euid" || a == "--uid":
+			user = true
+			i++
+		case strings.HasPrefix(a, "-u") || strings.HasPrefix(a, "-U") || strings.HasPrefix(a, "--euid=") || strings.HasPrefix(a, "--uid="):
+			user = true
+		case slices.Contains(pkillValueFlags, a):
+			i++
+		case strings.HasPrefix(a, "-"):
+		default:
+			patterns = append(patterns, a)
+		}
+	}
+	switch {
+	case len(patterns) == 0 && user:
+		v.add(SeverityHigh, c, "signals every process of the user")
+	case len(patterns) > 0 && matchesAll(patterns[0]):
+		v.add(SeverityHigh, c, "signals every process matching %q", patterns[0])
+	}
+}
+
+func checkKillall(v *Verdict, c simpleCommand) {
+	if c.name() == "killall5" {
+		v.add(SeverityHigh, c, "signals every process")
+		return
+	}
+	args := c.args()
+	user, regex := false, false
+	var names []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "-u" || a == "--user":
+			user = true
+			i++
+		case a == "-r" || a == "--regexp":
+			regex = true
+		case a == "-s" || a == "--signal" || a == "-o" || a == "--older-than" || a == "-y" || a == "--younger-than":
+			i++
+		case strings.HasPrefix(a, "-"):
+		default:
+			names = append(names, a)
+		}
+	}
+	switch {
+	case len(names) == 0 && user:
+		v.add(SeverityHigh, c, "signals every process of the user")
+	case regex && len(names) > 0 && matchesAll(names[0]):
+		v.add(SeverityHigh, c, "signals every process matching %q", names[0])
+	}
+}
+
+func checkRedirects(v *Verdict, c simpleCommand) {
+	for _, r := range c.redirects {
+		if !strings.Contains(r.op, ">") {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(r.target, "/dev/") && !isHarmlessDevice(r.target):
+			v.add(SeverityCritical, c, "writes directly to device %s", r.target)
+		case isSystemPath(r.target) || strings.HasPrefix(r.target, "/etc/") || strings.HasPrefix(r.target, "/boot/"):
+			v.add(SeverityHigh, c, "overwrites system file %s", r.target)
+		}
+	}
+}
+
+// splitFlags separates option words from operands, honouring "--".
+func splitFlags(args []string) (flags, operands []string) {
+	for i, a := range args {
+		if a == "--" {
+			return flags, append(operands, args[i+1:]...)
+		}
+		if strings.HasPrefix(a, "-") && a != "-" {
+			flags = append(flags, a)
+			continue
+		}
+		operands = append(operands, a)
+	}
+	return flags, operands
+}
+
+// hasFlag matches a short flag, alone or combined ("-rf"), or its long form.
+func hasFlag(flags []string, short rune, long string) bool {
+	for _, f := range flags {
+		if long != "" && f == long {
+			return true
+		}
+		if strings.HasPrefix(f, "--") {
+			continue
+		}
+		if strings.ContainsRune(f[1:], short) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRootOrHome reports whether p is the root directory, a home directory, a parent of one,
+// or everything in them. Spellings such as ~/., $HOME// or /home/alice/ are normalised first.
+func isRootOrHome(p string) bool {
+	if strings.HasPrefix(p, "//") {
+		return true
+	}
+	if rest, ok := homeRelative(p); ok {
+		// Clean against a stand-in for the home directory: anything that leaves it, like
+		// ~/.. or ~/../bob, is a parent or another user's home.
+		c := path.Clean("home" + rest)
+		return c == "home" || c == "home/*" || !strings.HasPrefix(c, "home/")
+	}
+	if !strings.HasPrefix(p, "/") {
+		return false
+	}
+	c := strings.TrimSuffix(path.Clean(p), "/*")
+	return c == "" || c == "/" || c == "/home" || path.Dir(c) == "/home"
+}
+
+// homeRelative returns the part of p after a leading home directory (~, ~user, $HOME or
+// ${HOME}); it is empty or starts with a slash.
+func homeRelative(p string) (string, bool) {
+	for _, h := range []string{"$HOME", "${HOME}"} {
+		if rest, found := strings.CutPrefix(p, h); found && (rest == "" || rest[0] == '/') {
+			return rest, true
+		}
+	}
+	if strings.HasPrefix(p, "~") {
+		if i := strings.IndexByte(p, '/'); i >= 0 {
+			return p[i:], true
+		}
+		return "", true
+	}
+	return "", false
+}
+
+var systemDirs = []string{
+	"/bin", "/boot", "/dev", "/etc", "/home", "/lib", "/lib32", "/lib64", "/opt",
+	"/proc", "/root", "/sbin", "/srv", "/sys", "/usr", "/var",
+}
+
+func isSystemPath(p string) bool {
+	if !strings.HasPrefix(p, "/") {
+		return false
+	}
+	cleaned := strings.TrimSuffix(path.Clean(strings.TrimSuffix(p, "/*")), "/")
+	if slices.Contains(systemDirs, cleaned) {
+		return true
+	}
+	// Top-level directories of /usr and /var are just as fatal to lose.
+	dir := path.Dir(cleaned)
+	return dir == "/usr" || dir == "/var"
+}
+
+func isHarmlessDevice(p string) bool {
+	switch p {
+	case "/dev/null", "/dev/zero", "/dev/stdout", "/dev/stderr", "/dev/tty":
+		return true
+	}
+	return strings.HasPrefix(p, "/dev/fd/") || strings.HasPrefix(p, "/dev/pts/")
+}
+
+func isShell(name string) bool {
+	switch name {
+	case "sh", "bash", "zsh", "dash", "ksh", "fish":
+		return true
+	}
+	return false
+}
+
+func isDownloader(name string) bool {
+	switch name {
+	case "curl", "wget", "fetch":
+		return true
+	}
+	return false
+}
+
+// inlineScript returns the script passed to a shell via -c.
+func inlineScript(c simpleCommand) (string, bool) {
+	args := c.args()
+	for i, a := range args {
+		if strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "--") && strings.Contains(a, "c") && i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,93 @@
+package danger
+
+import "testing"
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want Severity
+	}{
+		// Destructive commands, including ones hidden behind quoting, wrappers and pipes.
+		{`rm -fr /`, SeverityCritical},
+		{`rm -rf "$HOME"`, SeverityCritical},
+		{`rm -rf ~`, SeverityCritical},
+		{`rm -r -f /`, SeverityCritical},
+		{`rm --no-preserve-root -rf /`, SeverityCritical},
+		{`sudo dd if=/dev/zero of=/dev/sda`, SeverityCritical},
+		{`sudo dd of=/dev/sda`, SeverityCritical},
+		{`find / -delete`, SeverityCritical},
+		{`find ~ -name '*.log' -exec rm {} +`, SeverityCritical},
+		{`echo / | xargs rm -rf`, SeverityCritical},
+		{`printf '/\n' | sudo xargs rm -rf`, SeverityCritical},
+		{`env FOO=1 sudo -u root rm -rf /`, SeverityCritical},
+		{`sh -c "rm -rf /"`, SeverityCritical},
+		{`echo $(rm -rf /)`, SeverityCritical},
+		{`ls; rm -rf /`, SeverityCritical},
+		{`mkfs.ext4 /dev/sdb1`, SeverityCritical},
+
+		// Root and home directories however they are spelled.
+		{`rm -rf ~/.`, SeverityCritical},
+		{`rm -rf ~//`, SeverityCritical},
+		{`rm -rf "$HOME/."`, SeverityCritical},
+		{`rm -rf ${HOME}/./`, SeverityCritical},
+		{`rm -rf /./`, SeverityCritical},
+		{`rm -rf ~/..`, SeverityCritical},
+		{`rm -rf ~/../bob`, SeverityCritical},
+		{`rm -rf ~bob`, SeverityCritical},
+		{`rm -rf /home/alice`, SeverityCritical},
+		{`rm -rf /home/alice/`, SeverityCritical},
+		{`rm -rf /home/alice/*`, SeverityCritical},
+		{`rm -rf /home/*`, SeverityCritical},
+		{`rm -rf /home`, SeverityCritical},
+
+		{`:(){ :|:& };:`, SeverityCritical},
+		{`rm -rf /etc`, SeverityHigh},
+		{`curl -fsSL https://example.com/install.sh | sh`, SeverityHigh},
+		{`chmod -R 777 /`, SeverityHigh},
+		{`docker system prune -a`, SeverityHigh},
+
+		// Signalling every process.
+		{`kill -9 -1`, SeverityHigh},
+		{`kill -KILL -- -1`, SeverityHigh},
+		{`pkill -u $USER`, SeverityHigh},
+		{`pkill -9 -u alice`, SeverityHigh},
+		{`pkill -f .`, SeverityHigh},
+		{`killall -u alice`, SeverityHigh},
+		{`killall -r '.*'`, SeverityHigh},
+		{`sudo killall5 -9`, SeverityHigh},
+
+		// Findings that only warn.
+		{`git push --force`, SeverityMedium},
+		{`git reset --hard`, SeverityMedium},
+		{`rm -rf *`, SeverityMedium},
+		{`find . -name node_modules | xargs rm -rf`, SeverityMedium},
+		{`dd if=/dev/zero of=/dev/null count=1`, SeverityLow},
+
+		// Harmless commands that merely mention dangerous words.
+		{`echo add dd`, SeverityNone},
+		{`grep rm file`, SeverityNone},
+		{`echo "rm -rf /"`, SeverityNone},
+		{`git commit -m 'rm -rf / is bad'`, SeverityNone},
+		{`ls -la /`, SeverityNone},
+		{`rm -rf ./build`, SeverityNone},
+		{`rm file.txt`, SeverityNone},
+		{`find . -name '*.go'`, SeverityNone},
+		{`echo / | xargs ls`, SeverityNone},
+		{`rm -rf ~/project/build`, SeverityNone},
+		{`rm -rf "$HOME/.cache/go-build"`, SeverityNone},
+		{`rm -rf /home/alice/tmp`, SeverityNone},
+		{`kill 1234`, SeverityNone},
+		{`kill -9 %1`, SeverityNone},
+		{`pkill -u alice node`, SeverityNone},
+		{`pkill -f 'python server.py'`, SeverityNone},
+		{`killall node`, SeverityNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cmd, func(t *testing.T) {
+			v := Analyze(tt.cmd)
+			if v.Severity != tt.want {
+				t.Errorf("Analyze(%q).Severity = %s, want %s (reasons: %v)", tt.cmd, v.Severity, tt.want, v.Reasons)
+			}
+		})
+	}
+}
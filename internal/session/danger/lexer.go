@@ -0,0 +1,192 @@
+package danger
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokOp
+	tokRedirect
+)
+
+// token is a shell word or operator after quote removal.
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// lex splits a command line into words and control operators the way a POSIX shell would,
+// removing quotes. Command substitutions are returned separately so they can be analysed too.
+func lex(s string) ([]token, []string, error) {
+	var (
+		tokens []token
+		subs   []string
+		cur    strings.Builder
+		inWord bool
+	)
+
+	flush := func() {
+		if inWord {
+			tokens = append(tokens, token{kind: tokWord, text: cur.String()})
+			cur.Reset()
+			inWord = false
+		}
+	}
+
+	rs := []rune(s)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case r == '\\':
+			inWord = true
+			if i+1 < len(rs) {
+				i++
+				if rs[i] != '\n' {
+					cur.WriteRune(rs[i])
+				}
+			}
+
+		case r == '\'':
+			inWord = true
+			end := indexRune(rs, i+1, '\'')
+			if end < 0 {
+				return nil, nil, fmt.Errorf("unmatched single quote")
+			}
+			cur.WriteString(string(rs[i+1 : end]))
+			i = end
+
+		case r == '"':
+			inWord = true
+			j := i + 1
+			for ; j < len(rs) && rs[j] != '"'; j++ {
+				if rs[j] == '\\' && j+1 < len(rs) && strings.ContainsRune("\"\\$`", rs[j+1]) {
+					j++
+					cur.WriteRune(rs[j])
+					continue
+				}
+				if rs[j] == '$' && j+1 < len(rs) && rs[j+1] == '(' {
+					end, err := matchParen(rs, j+1)
+					if err != nil {
+						return nil, nil, err
+					}
+					subs = append(subs, string(rs[j+2:end]))
+					cur.WriteString(string(rs[j : end+1]))
+					j = end
+					continue
+				}
+				cur.WriteRune(rs[j])
+			}
+			if j >= len(rs) {
+				return nil, nil, fmt.Errorf("unmatched double quote")
+			}
+			i = j
+
+		case r == '$' && i+1 < len(rs) && rs[i+1] == '(':
+			end, err := matchParen(rs, i+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			inWord = true
+			subs = append(subs, string(rs[i+2:end]))
+			cur.WriteString(string(rs[i : end+1]))
+			i = end
+
+		case r == '`':
+			end := indexRune(rs, i+1, '`')
+			if end < 0 {
+				return nil, nil, fmt.Errorf("unmatched backquote")
+			}
+			inWord = true
+			subs = append(subs, string(rs[i+1:end]))
+			cur.WriteString(string(rs[i : end+1]))
+			i = end
+
+		case r == ' ' || r == '\t':
+			flush()
+
+		case r == '\n' || r == ';' || r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, token{kind: tokOp, text: string(r)})
+
+		case r == '|' || r == '&':
+			flush()
+			op := string(r)
+			if i+1 < len(rs) && (rs[i+1] == r || (r == '|' && rs[i+1] == '&')) {
+				op += string(rs[i+1])
+				i++
+			} else if r == '&' && i+1 < len(rs) && rs[i+1] == '>' {
+				op = "&>"
+				i++
+				if i+1 < len(rs) && rs[i+1] == '>' {
+					op += ">"
+					i++
+				}
+				tokens = append(tokens, token{kind: tokRedirect, text: op})
+				continue
+			}
+			tokens = append(tokens, token{kind: tokOp, text: op})
+
+		case r == '>' || r == '<':
+			// A leading fd number ("2>") belongs to the redirection, not to the previous word.
+			if inWord && isDigits(cur.String()) {
+				cur.Reset()
+				inWord = false
+			}
+			flush()
+			op := string(r)
+			for i+1 < len(rs) && (rs[i+1] == '>' || rs[i+1] == '&' || rs[i+1] == '|') {
+				op += string(rs[i+1])
+				i++
+			}
+			tokens = append(tokens, token{kind: tokRedirect, text: op})
+
+		default:
+			inWord = true
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens, subs, nil
+}
+
+func indexRune(rs []rune, from int, target rune) int {
+	for i := from; i < len(rs); i++ {
+		if rs[i] == target {
+			return i
+		}
+	}
+	return -1
+}
+
+// matchParen returns the index of the parenthesis closing the one at open.
+func matchParen(rs []rune, open int) (int, error) {
+	depth := 0
+	for i := open; i < len(rs); i++ {
+		switch rs[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return -1, fmt.Errorf("unmatched parenthesis")
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
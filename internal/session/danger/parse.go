@@ -0,0 +1,165 @@
+package danger
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var inlineNameRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// simpleCommand is one argv with its redirections, after wrapper resolution.
+type simpleCommand struct {
+	argv      []string
+	redirects []redirect
+	wrappers  []string
+	// piped is set when argv includes the arguments xargs reads from an echo or printf
+	// earlier in the pipeline.
+	piped bool
+}
+
+type redirect struct {
+	op     string
+	target string
+}
+
+// pipeline groups simple commands connected with | or |&.
+type pipeline []simpleCommand
+
+func (c simpleCommand) name() string {
+	if len(c.argv) == 0 {
+		return ""
+	}
+	return filepath.Base(c.argv[0])
+}
+
+func (c simpleCommand) args() []string {
+	if len(c.argv) < 2 {
+		return nil
+	}
+	return c.argv[1:]
+}
+
+func (c simpleCommand) String() string {
+	return strings.Join(append(append([]string{}, c.wrappers...), c.argv...), " ")
+}
+
+// parse groups tokens into pipelines of simple commands.
+func parse(tokens []token) []pipeline {
+	var (
+		out  []pipeline
+		pipe pipeline
+		cmd  simpleCommand
+	)
+
+	endCommand := func() {
+		if len(cmd.argv) > 0 || len(cmd.redirects) > 0 {
+			pipe = append(pipe, cmd)
+		}
+		cmd = simpleCommand{}
+	}
+	endPipeline := func() {
+		endCommand()
+		if len(pipe) > 0 {
+			out = append(out, pipe)
+		}
+		pipe = nil
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch t.kind {
+		case tokWord:
+			cmd.argv = append(cmd.argv, t.text)
+		case tokRedirect:
+			r := redirect{op: t.text}
+			if i+1 < len(tokens) && tokens[i+1].kind == tokWord {
+				r.target = tokens[i+1].text
+				i++
+			}
+			cmd.redirects = append(cmd.redirects, r)
+		case tokOp:
+			if t.text == "|" || t.text == "|&" {
+				endCommand()
+				continue
+			}
+			endPipeline()
+		}
+	}
+	endPipeline()
+
+	for _, p := range out {
+		for i := range p {
+			p[i] = unwrap(p[i])
+		}
+	}
+	return out
+}
+
+// wrapperOptsWithArg lists the options of each wrapper that consume the following word.
+var wrapperOptsWithArg = map[string][]string{
+	"sudo":    {"-u", "-g", "-C", "-h", "-p", "-r", "-t", "-U", "-D"},
+	"doas":    {"-u", "-C"},
+	"env":     {"-u", "-C", "-S", "--unset", "--chdir"},
+	"xargs":   {"-I", "-n", "-P", "-L", "-d", "-E", "-s", "-a", "--max-args", "--max-procs", "--delimiter", "--arg-file"},
+	"nice":    {"-n", "--adjustment"},
+	"ionice":  {"-c", "-n", "-p", "--class", "--classdata"},
+	"stdbuf":  {"-i", "-o", "-e"},
+	"timeout": {"-s", "-k", "--signal", "--kill-after"},
+	"time":    {"-f", "-o", "--format", "--output"},
+}
+
+var plainWrappers = map[string]bool{
+	"nohup": true, "command": true, "exec": true, "builtin": true, "time": true,
+}
+
+// unwrap strips leading assignments and wrapper commands such as sudo, env and xargs
+// so checks apply to the command that actually runs.
+func unwrap(c simpleCommand) simpleCommand {
+	argv := c.argv
+	for len(argv) > 0 {
+		if isAssignment(argv[0]) {
+			argv = argv[1:]
+			continue
+		}
+
+		name := filepath.Base(argv[0])
+		opts, known := wrapperOptsWithArg[name]
+		if !known && !plainWrappers[name] {
+			break
+		}
+
+		c.wrappers = append(c.wrappers, name)
+		argv = argv[1:]
+		for len(argv) > 0 {
+			a := argv[0]
+			if a == "--" {
+				argv = argv[1:]
+				break
+			}
+			if name == "env" && isAssignment(a) {
+				argv = argv[1:]
+				continue
+			}
+			if !strings.HasPrefix(a, "-") || a == "-" {
+				break
+			}
+			argv = argv[1:]
+			if slices.Contains(opts, a) && len(argv) > 0 {
+				argv = argv[1:]
+			}
+		}
+		// timeout takes a mandatory duration before the command.
+		if name == "timeout" && len(argv) > 0 {
+			argv = argv[1:]
+		}
+	}
+	c.argv = argv
+	return c
+}
+
+func isAssignment(s string) bool {
+	i := strings.Index(s, "=")
+	return i > 0 && inlineNameRE.MatchString(s[:i])
+}
@@ -0,0 +1,220 @@
+package danger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Action is what a policy rule asks the caller to do with a matching command.
+type Action string
+
+const (
+	ActionAllow   Action = "allow"
+	ActionWarn    Action = "warn"
+	ActionConfirm Action = "confirm"
+	ActionBlock   Action = "block"
+)
+
+// rank orders actions from most to least permissive.
+var rank = map[Action]int{ActionAllow: 0, ActionWarn: 1, ActionConfirm: 2, ActionBlock: 3}
+
+// stricter reports whether a is stricter than b.
+func (a Action) stricter(b Action) bool {
+	return rank[a] > rank[b]
+}
+
+// When restricts a rule to a working directory, git branch or environment.
+type When struct {
+	CWD    string            `yaml:"cwd,omitempty"`
+	Branch string            `yaml:"branch,omitempty"`
+	Env    map[string]string `yaml:"env,omitempty"`
+}
+
+// Rule matches commands by regular expression and assigns an action when its scope applies.
+type Rule struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+	When    When   `yaml:"when,omitempty"`
+	Action  Action `yaml:"action"`
+	Message string `yaml:"message,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// Policy is an ordered list of user rules layered on top of Analyze.
+type Policy struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Scope describes where a command is about to run.
+type Scope struct {
+	CWD    string
+	Branch string
+	Env    func(string) string
+}
+
+// Decision is the combined outcome of the built-in analyser and the policy.
+type Decision struct {
+	Action  Action
+	Rule    string
+	Message string
+	Verdict Verdict
+}
+
+// LoadPolicy reads a policy file. A blank or missing path yields an empty policy.
+func LoadPolicy(path string) (*Policy, error) {
+	p := &Policy{}
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return p, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return p, nil
+		}
+		return nil, fmt.Errorf("aish: cannot read %q: %w", path, err)
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return p, nil
+	}
+	if err := yaml.Unmarshal(b, p); err != nil {
+		return nil, fmt.Errorf("aish: invalid YAML in %q: %w", path, err)
+	}
+
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if strings.TrimSpace(r.Name) == "" {
+			r.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		switch r.Action {
+		case ActionAllow, ActionWarn, ActionConfirm, ActionBlock:
+		default:
+			return nil, fmt.Errorf("aish: policy rule %q in %q: unknown action %q", r.Name, path, r.Action)
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("aish: policy rule %q in %q: %w", r.Name, path, err)
+		}
+		r.re = re
+	}
+	return p, nil
+}
+
+// CurrentScope captures the working directory, git branch and environment of this process.
+func CurrentScope() Scope {
+	cwd, _ := os.Getwd()
+	return Scope{CWD: cwd, Branch: gitBranch(cwd), Env: os.Getenv}
+}
+
+// Evaluate decides what to do with cmd. The first matching rule wins; without one the
+// analyser's verdict applies. A rule can relax findings below critical, but on a critical
+// finding the stricter of the rule's and the analyser's action applies, so no rule can turn
+// a block into a prompt.
+func (p *Policy) Evaluate(cmd string, scope Scope) Decision {
+	verdict := Analyze(cmd)
+	d := Decision{Action: ActionAllow, Verdict: verdict}
+	switch {
+	case verdict.Dangerous():
+		d.Action = ActionBlock
+	case verdict.Severity > SeverityNone:
+		d.Action = ActionWarn
+	}
+
+	if p == nil {
+		return d
+	}
+
+	for _, r := range p.Rules {
+		if !r.matches(cmd) || !r.When.applies(scope) {
+			continue
+		}
+		if verdict.Severity < SeverityCritical || r.Action.stricter(d.Action) {
+			d.Action = r.Action
+		}
+		d.Rule = r.Name
+		d.Message = r.Message
+		return d
+	}
+	return d
+}
+
+// matches tests the raw command and each resolved command so wrappers like sudo do not hide a match.
+func (r Rule) matches(cmd string) bool {
+	if r.re == nil {
+		return false
+	}
+	if r.re.MatchString(cmd) {
+		return true
+	}
+	tokens, _, err := lex(cmd)
+	if err != nil {
+		return false
+	}
+	for _, p := range parse(tokens) {
+		for _, c := range p {
+			if r.re.MatchString(strings.Join(c.argv, " ")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (w When) applies(scope Scope) bool {
+	if w.CWD != "" && !globMatch(expandHome(w.CWD), scope.CWD) {
+		return false
+	}
+	if w.Branch != "" && !globMatch(w.Branch, scope.Branch) {
+		return false
+	}
+	for k, pattern := range w.Env {
+		value := ""
+		if scope.Env != nil {
+			value = scope.Env(k)
+		}
+		if !globMatch(pattern, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// globMatch is filepath.Match with "**" matching the pattern's directory and everything below it.
+func globMatch(pattern, value string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return value == prefix || strings.HasPrefix(value, prefix+"/")
+	}
+	ok, err := filepath.Match(pattern, value)
+	return err == nil && ok
+}
+
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, strings.TrimPrefix(p, "~"))
+}
+
+func gitBranch(dir string) string {
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
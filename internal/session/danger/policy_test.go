@@ -0,0 +1,48 @@
+package danger
+
+import (
+	"regexp"
+	"testing"
+)
+
+func rule(name, pattern string, action Action) Rule {
+	return Rule{Name: name, Pattern: pattern, Action: action, re: regexp.MustCompile(pattern)}
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	scope := Scope{CWD: "/work/app", Branch: "main", Env: func(string) string { return "" }}
+
+	tests := []struct {
+		name   string
+		rules  []Rule
+		cmd    string
+		action Action
+		rule   string
+	}{
+		{"no rules, harmless", nil, `ls`, ActionAllow, ""},
+		{"no rules, warning", nil, `git push --force`, ActionWarn, ""},
+		{"no rules, dangerous", nil, `rm -rf /etc`, ActionBlock, ""},
+
+		{"rule tightens a harmless command", []Rule{rule("kube", `^kubectl\s+delete\b`, ActionConfirm)}, `kubectl delete pod x`, ActionConfirm, "kube"},
+		{"rule relaxes a high finding", []Rule{rule("etc", `rm -rf /etc`, ActionAllow)}, `rm -rf /etc`, ActionAllow, "etc"},
+		{"first match wins", []Rule{rule("a", `^git`, ActionWarn), rule("b", `^git`, ActionBlock)}, `git status`, ActionWarn, "a"},
+
+		// No rule may turn a critical block into something weaker.
+		{"allow cannot relax critical", []Rule{rule("any", `.`, ActionAllow)}, `rm -rf /`, ActionBlock, "any"},
+		{"warn cannot relax critical", []Rule{rule("kube", `^kubectl\s+delete\b`, ActionWarn)}, `kubectl delete x; rm -rf /`, ActionBlock, "kube"},
+		{"confirm cannot relax critical", []Rule{rule("kube", `^kubectl\s+delete\b`, ActionConfirm)}, `kubectl delete x; rm -rf /`, ActionBlock, "kube"},
+		{"block stays block", []Rule{rule("rm", `rm`, ActionBlock)}, `rm -rf /`, ActionBlock, "rm"},
+
+		{"rule scoped to another directory is skipped", []Rule{{Name: "infra", Pattern: `^terraform`, Action: ActionBlock, When: When{CWD: "/work/infra/**"}, re: regexp.MustCompile(`^terraform`)}}, `terraform destroy`, ActionAllow, ""},
+		{"rule matches behind sudo", []Rule{rule("apt", `^apt\s+remove`, ActionConfirm)}, `sudo apt remove vim`, ActionConfirm, "apt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Policy{Rules: tt.rules}
+			d := p.Evaluate(tt.cmd, scope)
+			if d.Action != tt.action || d.Rule != tt.rule {
+				t.Errorf("Evaluate(%q) = %s (rule %q), want %s (rule %q)", tt.cmd, d.Action, d.Rule, tt.action, tt.rule)
+			}
+		})
+	}
+}
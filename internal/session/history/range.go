@@ -0,0 +1,75 @@
+package history
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mr-gaber/ai-shell/internal/utils"
+)
+
+// IsHelper reports whether cmd is an aish helper (ai or snip) rather than user work.
+func IsHelper(cmd string) bool {
+	t := strings.TrimSpace(cmd)
+	return strings.HasPrefix(t, "ai ") || strings.HasPrefix(t, "snip ")
+}
+
+// Offset returns the current end of the history file, marking where the next entry will start.
+func Offset(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading session history: %w", err)
+	}
+	return info.Size(), nil
+}
+
+// Since returns the entries appended to the history file after offset.
+func Since(path string, offset int64) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading session history: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("reading session history: %w", err)
+	}
+	var lines []string
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading session history: %w", err)
+	}
+	return utils.ParseJSONL(lines, func(e Entry) bool { return e.Cmd != "" }), nil
+}
+
+// All returns every entry in the history file with Seq set to its 1-based line number.
+func All(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading session history: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		for _, e := range utils.ParseJSONL([]string{sc.Text()}, func(e Entry) bool { return e.Cmd != "" }) {
+			e.Seq = n
+			entries = append(entries, e)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading session history: %w", err)
+	}
+	return entries, nil
+}
@@ -7,6 +7,17 @@ import (
 	"github.com/mr-gaber/ai-shell/internal/utils"
 )
 
+// Entry is one command recorded by the shell prompt hook in history.jsonl.
+type Entry struct {
+	TS   string `json:"ts"`
+	CWD  string `json:"cwd"`
+	Cmd  string `json:"cmd"`
+	Exit int    `json:"exit"`
+	Git  string `json:"git"`
+	// Seq is the entry's line number in history.jsonl, set by All.
+	Seq int `json:"-"`
+}
+
 // Reader tails the session history JSONL file.
 type Reader struct {
 	Path     string
@@ -0,0 +1,49 @@
+package project
+
+import (
+	"fmt"
+	"strings"
+)
+
+// gitStatusLines caps how many porcelain entries are listed.
+const gitStatusLines = 30
+
+type gitRepo struct{}
+
+func (gitRepo) Name() string { return "git" }
+
+func (gitRepo) Collect(dir string) (string, error) {
+	if _, err := output(dir, "git", "rev-parse", "--is-inside-work-tree"); err != nil {
+		return "", nil
+	}
+
+	var b strings.Builder
+	if branch, err := output(dir, "git", "rev-parse", "--abbrev-ref", "HEAD"); err == nil {
+		fmt.Fprintf(&b, "branch: %s\n", branch)
+	}
+
+	status, err := output(dir, "git", "status", "--porcelain")
+	if err != nil {
+		return "", err
+	}
+	if status == "" {
+		b.WriteString("working tree clean\n")
+		return b.String(), nil
+	}
+
+	lines := strings.Split(status, "\n")
+	b.WriteString("status (porcelain):\n")
+	for i, ln := range lines {
+		if i == gitStatusLines {
+			fmt.Fprintf(&b, "… %d more\n", len(lines)-gitStatusLines)
+			break
+		}
+		b.WriteString(ln + "\n")
+	}
+
+	if stat, err := output(dir, "git", "diff", "--stat", "HEAD"); err == nil && stat != "" {
+		statLines := strings.Split(stat, "\n")
+		fmt.Fprintf(&b, "diff summary: %s\n", strings.TrimSpace(statLines[len(statLines)-1]))
+	}
+	return b.String(), nil
+}
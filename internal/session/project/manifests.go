@@ -0,0 +1,193 @@
+package project
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type goModule struct{}
+
+func (goModule) Name() string { return "go" }
+
+func (goModule) Collect(dir string) (string, error) {
+	lines, err := readLines(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", nil
+	}
+
+	var b strings.Builder
+	requires := 0
+	inRequire := false
+	for _, ln := range lines {
+		switch {
+		case strings.HasPrefix(ln, "module "), strings.HasPrefix(ln, "go "), strings.HasPrefix(ln, "toolchain "):
+			b.WriteString(ln + "\n")
+		case ln == "require (":
+			inRequire = true
+		case inRequire && ln == ")":
+			inRequire = false
+		case inRequire && ln != "" && !strings.Contains(ln, "// indirect"):
+			requires++
+		case strings.HasPrefix(ln, "require ") && !strings.Contains(ln, "// indirect"):
+			requires++
+		}
+	}
+	fmt.Fprintf(&b, "direct dependencies: %d\n", requires)
+	if v, err := output(dir, "go", "env", "GOVERSION"); err == nil && v != "" {
+		fmt.Fprintf(&b, "installed toolchain: %s\n", v)
+	}
+	return b.String(), nil
+}
+
+type nodePackage struct{}
+
+func (nodePackage) Name() string { return "node" }
+
+func (nodePackage) Collect(dir string) (string, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return "", nil
+	}
+	var pkg struct {
+		Name           string            `json:"name"`
+		Version        string            `json:"version"`
+		PackageManager string            `json:"packageManager"`
+		Engines        map[string]string `json:"engines"`
+		Scripts        map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(raw, &pkg); err != nil {
+		return "", fmt.Errorf("package.json: %w", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "package: %s %s\n", pkg.Name, pkg.Version)
+	if pkg.PackageManager != "" {
+		fmt.Fprintf(&b, "package manager: %s\n", pkg.PackageManager)
+	}
+	for _, lock := range []string{"package-lock.json", "yarn.lock", "pnpm-lock.yaml", "bun.lockb"} {
+		if _, err := os.Stat(filepath.Join(dir, lock)); err == nil {
+			fmt.Fprintf(&b, "lockfile: %s\n", lock)
+		}
+	}
+	for _, k := range sortedKeys(pkg.Engines) {
+		fmt.Fprintf(&b, "engine %s: %s\n", k, pkg.Engines[k])
+	}
+	if len(pkg.Scripts) > 0 {
+		b.WriteString("scripts:\n")
+		for _, k := range sortedKeys(pkg.Scripts) {
+			fmt.Fprintf(&b, "  %s: %s\n", k, pkg.Scripts[k])
+		}
+	}
+	return b.String(), nil
+}
+
+type cargoCrate struct{}
+
+func (cargoCrate) Name() string { return "rust" }
+
+var tomlKeyRE = regexp.MustCompile(`^(name|version|edition|rust-version|members)\s*=\s*(.+)$`)
+
+func (cargoCrate) Collect(dir string) (string, error) {
+	lines, err := readLines(filepath.Join(dir, "Cargo.toml"))
+	if err != nil {
+		return "", nil
+	}
+
+	var b strings.Builder
+	section := ""
+	for _, ln := range lines {
+		if strings.HasPrefix(ln, "[") {
+			section = strings.Trim(ln, "[] ")
+			continue
+		}
+		if section != "package" && section != "workspace" {
+			continue
+		}
+		if m := tomlKeyRE.FindStringSubmatch(ln); m != nil {
+			fmt.Fprintf(&b, "%s.%s: %s\n", section, m[1], m[2])
+		}
+	}
+	return b.String(), nil
+}
+
+type dockerfile struct{}
+
+func (dockerfile) Name() string { return "docker" }
+
+func (dockerfile) Collect(dir string) (string, error) {
+	lines, err := readLines(filepath.Join(dir, "Dockerfile"))
+	if err != nil {
+		return "", nil
+	}
+
+	var b strings.Builder
+	for _, ln := range lines {
+		instr, _, _ := strings.Cut(ln, " ")
+		switch strings.ToUpper(instr) {
+		case "FROM", "EXPOSE", "WORKDIR", "ENTRYPOINT", "CMD":
+			b.WriteString(ln + "\n")
+		}
+	}
+	for _, compose := range []string{"docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, compose)); err == nil {
+			fmt.Fprintf(&b, "compose file: %s\n", compose)
+		}
+	}
+	return b.String(), nil
+}
+
+type makefile struct{}
+
+func (makefile) Name() string { return "make" }
+
+var makeTargetRE = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_./-]*)\s*:([^=]|$)`)
+
+func (makefile) Collect(dir string) (string, error) {
+	lines, err := readLines(filepath.Join(dir, "Makefile"))
+	if err != nil {
+		return "", nil
+	}
+
+	var targets []string
+	seen := map[string]bool{}
+	for _, ln := range lines {
+		if m := makeTargetRE.FindStringSubmatch(ln); m != nil && !seen[m[1]] {
+			seen[m[1]] = true
+			targets = append(targets, m[1])
+		}
+	}
+	if len(targets) == 0 {
+		return "", nil
+	}
+	return "targets: " + strings.Join(targets, ", "), nil
+}
+
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		lines = append(lines, strings.TrimSpace(sc.Text()))
+	}
+	return lines, sc.Err()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
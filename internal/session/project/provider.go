@@ -0,0 +1,75 @@
+package project
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Provider contributes facts about the project found in a directory.
+// Collect returns an empty string when the provider does not apply.
+type Provider interface {
+	Name() string
+	Collect(dir string) (string, error)
+}
+
+// Section is the budgeted output of one provider.
+type Section struct {
+	Name string
+	Body string
+}
+
+// Defaults returns the built-in providers in the order their sections are emitted.
+func Defaults() []Provider {
+	return []Provider{
+		goModule{},
+		nodePackage{},
+		cargoCrate{},
+		dockerfile{},
+		makefile{},
+		gitRepo{},
+	}
+}
+
+// Collect runs every provider against dir, truncating each section to budget bytes.
+// Providers that fail or do not apply are skipped.
+func Collect(dir string, providers []Provider, budget int) []Section {
+	if budget <= 0 || strings.TrimSpace(dir) == "" {
+		return nil
+	}
+
+	sections := make([]Section, 0, len(providers))
+	for _, p := range providers {
+		body, err := p.Collect(dir)
+		body = strings.TrimSpace(body)
+		if err != nil || body == "" {
+			continue
+		}
+		sections = append(sections, Section{Name: p.Name(), Body: truncate(body, budget)})
+	}
+	return sections
+}
+
+func truncate(s string, budget int) string {
+	if len(s) <= budget {
+		return s
+	}
+	cut := strings.LastIndex(s[:budget], "\n")
+	if cut <= 0 {
+		cut = budget
+	}
+	return s[:cut] + "\n… (truncated)"
+}
+
+// commandTimeout bounds external tools such as git so context building stays fast.
+const commandTimeout = 2 * time.Second
+
+func output(dir, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return strings.TrimRight(string(out), "\n"), err
+}
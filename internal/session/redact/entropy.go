@@ -0,0 +1,86 @@
+package redact
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+const entropyRule = "entropy"
+
+type span struct {
+	start int
+	end   int
+}
+
+// highEntropyTokens finds word-like tokens whose character distribution looks random enough to be a secret.
+func highEntropyTokens(s string, cfg Entropy) []span {
+	minLen := cfg.MinLength
+	if minLen <= 0 {
+		minLen = 20
+	}
+	threshold := cfg.Threshold
+	if threshold <= 0 {
+		threshold = 4.0
+	}
+
+	var out []span
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		tok := s[start:end]
+		if len(tok) >= minLen && mixedClasses(tok) && shannon(tok) >= threshold {
+			out = append(out, span{start: start, end: end})
+		}
+		start = -1
+	}
+
+	for i, r := range s {
+		if isTokenRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(s))
+	return out
+}
+
+func isTokenRune(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("+/_-=.", r))
+}
+
+// mixedClasses filters out paths, words and plain numbers by requiring letters and digits.
+func mixedClasses(tok string) bool {
+	if strings.Count(tok, "/") > 1 || strings.Count(tok, ".") > 1 {
+		return false
+	}
+	var letters, digits bool
+	for _, r := range tok {
+		switch {
+		case unicode.IsLetter(r):
+			letters = true
+		case unicode.IsDigit(r):
+			digits = true
+		}
+	}
+	return letters && digits
+}
+
+func shannon(s string) float64 {
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+	n := float64(len(s))
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
@@ -0,0 +1,168 @@
+package redact
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretGroup names the capture group holding the sensitive part of a match.
+// Rules without it mask the whole match.
+const secretGroup = "secret"
+
+// Rule describes a single named pattern that should be masked before text leaves the machine.
+type Rule struct {
+	Name    string   `yaml:"name"`
+	Pattern string   `yaml:"pattern"`
+	Replace string   `yaml:"replace,omitempty"`
+	Allow   []string `yaml:"allow,omitempty"`
+}
+
+// Entropy configures detection of random-looking tokens that no rule matched.
+type Entropy struct {
+	Enabled   bool    `yaml:"enabled"`
+	MinLength int     `yaml:"min_length,omitempty"`
+	Threshold float64 `yaml:"threshold,omitempty"`
+}
+
+// File is the on-disk representation of the redaction configuration.
+type File struct {
+	Defaults *bool    `yaml:"defaults,omitempty"`
+	Rules    []Rule   `yaml:"rules,omitempty"`
+	Allow    []string `yaml:"allow,omitempty"`
+	Entropy  *Entropy `yaml:"entropy,omitempty"`
+}
+
+type compiledRule struct {
+	name    string
+	re      *regexp.Regexp
+	group   int
+	replace string
+	allow   []*regexp.Regexp
+}
+
+// DefaultRules returns the built-in rule set used when no configuration overrides it.
+func DefaultRules() []Rule {
+	return []Rule{
+		{
+			Name:    "private-key",
+			Pattern: `-----BEGIN [A-Z ]+PRIVATE KEY-----[.\s\S]+?-----END [A-Z ]+PRIVATE KEY-----`,
+			Replace: "[REDACTED_PRIVATE_KEY]",
+		},
+		{
+			Name:    "bearer",
+			Pattern: `(?i)bearer\s+(?P<secret>[A-Za-z0-9\-\._~\+/]+=*)`,
+		},
+		{
+			Name:    "api-key",
+			Pattern: `(?i)(?:api|token|secret|key)\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9_\-\.]{12,})["']?`,
+		},
+		{
+			Name:    "env-line",
+			Pattern: `(?m)^[A-Z0-9_]{3,}\s*=\s*(?P<secret>.+)$`,
+		},
+		{
+			Name:    "hex-long",
+			Pattern: `\b[0-9A-Fa-f]{24,}\b`,
+			Replace: "[HEX_REDACTED]",
+		},
+		{
+			Name:    "base64-long",
+			Pattern: `\b[A-Za-z0-9+/]{32,}={0,2}\b`,
+			Replace: "[B64_REDACTED]",
+		},
+	}
+}
+
+// DefaultAllow returns values that are never masked, such as full git commit hashes.
+func DefaultAllow() []string {
+	return []string{`^[0-9a-f]{40}$`}
+}
+
+// DefaultEntropy returns the entropy settings applied when the config omits them.
+func DefaultEntropy() Entropy {
+	return Entropy{Enabled: true, MinLength: 20, Threshold: 4.0}
+}
+
+// Load reads a redaction config from path and compiles it. A blank or missing path yields the defaults.
+func Load(path string) (*Redactor, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return New(DefaultRules(), DefaultAllow(), DefaultEntropy())
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return New(DefaultRules(), DefaultAllow(), DefaultEntropy())
+		}
+		return nil, fmt.Errorf("aish: cannot read %q: %w", path, err)
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return New(DefaultRules(), DefaultAllow(), DefaultEntropy())
+	}
+
+	var f File
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("aish: invalid YAML in %q: %w", path, err)
+	}
+
+	rules, allow := f.Rules, f.Allow
+	if f.Defaults == nil || *f.Defaults {
+		rules = append(DefaultRules(), rules...)
+		allow = append(DefaultAllow(), allow...)
+	}
+	entropy := DefaultEntropy()
+	if f.Entropy != nil {
+		entropy = *f.Entropy
+	}
+
+	r, err := New(rules, allow, entropy)
+	if err != nil {
+		return nil, fmt.Errorf("aish: invalid redaction rules in %q: %w", path, err)
+	}
+	return r, nil
+}
+
+func compileRule(rule Rule) (compiledRule, error) {
+	if strings.TrimSpace(rule.Name) == "" {
+		return compiledRule{}, fmt.Errorf("rule with pattern %q has no name", rule.Pattern)
+	}
+	re, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return compiledRule{}, fmt.Errorf("rule %q: %w", rule.Name, err)
+	}
+	allow, err := compileAll(rule.Allow)
+	if err != nil {
+		return compiledRule{}, fmt.Errorf("rule %q: %w", rule.Name, err)
+	}
+
+	replace := rule.Replace
+	if replace == "" {
+		replace = "[REDACTED]"
+	}
+
+	group := re.SubexpIndex(secretGroup)
+	if group < 0 {
+		group = 0
+	}
+
+	return compiledRule{name: rule.Name, re: re, group: group, replace: replace, allow: allow}, nil
+}
+
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("allow pattern %q: %w", p, err)
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
@@ -1,36 +1,153 @@
 package redact
 
 import (
+	"fmt"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 )
 
-// Scrub removes patterns resembling sensitive credentials from the provided text.
+// Redactor masks sensitive values using a precompiled rule set.
+type Redactor struct {
+	rules   []compiledRule
+	allow   []*regexp.Regexp
+	entropy Entropy
+}
+
+// Finding describes a single masked span in the original text.
+type Finding struct {
+	Rule  string
+	Line  int
+	Start int
+	End   int
+	Value string
+}
+
+// New compiles the provided rules and global allow-list into a Redactor.
+func New(rules []Rule, allow []string, entropy Entropy) (*Redactor, error) {
+	r := &Redactor{entropy: entropy}
+	for _, rule := range rules {
+		c, err := compileRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		r.rules = append(r.rules, c)
+	}
+	globalAllow, err := compileAll(allow)
+	if err != nil {
+		return nil, err
+	}
+	r.allow = globalAllow
+	return r, nil
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultRedactor *Redactor
+)
+
+// Default returns the shared Redactor built from DefaultRules.
+func Default() *Redactor {
+	defaultOnce.Do(func() {
+		r, err := New(DefaultRules(), DefaultAllow(), DefaultEntropy())
+		if err != nil {
+			panic(fmt.Sprintf("redact: invalid default rules: %v", err))
+		}
+		defaultRedactor = r
+	})
+	return defaultRedactor
+}
+
+// Scrub removes patterns resembling sensitive credentials from the provided text using the default rules.
 func Scrub(s string) string {
+	return Default().Scrub(s)
+}
+
+// Scrub masks every finding in s with its rule's replacement label.
+func (r *Redactor) Scrub(s string) string {
+	return r.replace(s, func(f Finding) string {
+		return r.label(f.Rule)
+	})
+}
+
+// Findings reports the spans Scrub would mask, in order of appearance.
+func (r *Redactor) Findings(s string) []Finding {
 	if s == "" {
+		return nil
+	}
+
+	var findings []Finding
+	taken := func(start, end int) bool {
+		for _, f := range findings {
+			if start < f.End && end > f.Start {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, rule := range r.rules {
+		for _, m := range rule.re.FindAllStringSubmatchIndex(s, -1) {
+			start, end := m[2*rule.group], m[2*rule.group+1]
+			if start < 0 || start == end || taken(start, end) {
+				continue
+			}
+			value := s[start:end]
+			if matchesAny(rule.allow, value) || matchesAny(r.allow, value) {
+				continue
+			}
+			findings = append(findings, Finding{Rule: rule.name, Start: start, End: end, Value: value})
+		}
+	}
+
+	if r.entropy.Enabled {
+		for _, tok := range highEntropyTokens(s, r.entropy) {
+			if taken(tok.start, tok.end) || matchesAny(r.allow, s[tok.start:tok.end]) {
+				continue
+			}
+			findings = append(findings, Finding{Rule: entropyRule, Start: tok.start, End: tok.end, Value: s[tok.start:tok.end]})
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Start < findings[j].Start })
+	for i := range findings {
+		findings[i].Line = strings.Count(s[:findings[i].Start], "\n") + 1
+	}
+	return findings
+}
+
+func (r *Redactor) replace(s string, mask func(Finding) string) string {
+	findings := r.Findings(s)
+	if len(findings) == 0 {
 		return s
 	}
 
-	var (
-		rePrivKey = regexp.MustCompile(`-----BEGIN [A-Z ]+PRIVATE KEY-----[.\s\S]+?-----END [A-Z ]+PRIVATE KEY-----`)
-		reBearer  = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9\-\._~\+/]+=*`)
-		reAPIKey  = regexp.MustCompile(`(?i)(api|token|secret|key)\s*[:=]\s*["']?[A-Za-z0-9_\-\.]{12,}["']?`)
-		reHexLong = regexp.MustCompile(`\b[0-9A-Fa-f]{24,}\b`)
-		reB64Long = regexp.MustCompile(`\b[A-Za-z0-9+/]{32,}={0,2}\b`)
-		reEnvLine = regexp.MustCompile(`(?m)^(?:[A-Z0-9_]{3,})\s*=\s*.+$`)
-	)
-
-	out := s
-	out = rePrivKey.ReplaceAllString(out, "[REDACTED_PRIVATE_KEY]")
-	out = reBearer.ReplaceAllString(out, "Bearer [REDACTED]")
-	out = reAPIKey.ReplaceAllString(out, "$1: [REDACTED]")
-	out = reHexLong.ReplaceAllString(out, "[HEX_REDACTED]")
-	out = reB64Long.ReplaceAllString(out, "[B64_REDACTED]")
-	out = reEnvLine.ReplaceAllStringFunc(out, func(line string) string {
-		if i := strings.Index(line, "="); i > 0 {
-			return line[:i+1] + " [REDACTED]"
+	var b strings.Builder
+	last := 0
+	for _, f := range findings {
+		b.WriteString(s[last:f.Start])
+		b.WriteString(mask(f))
+		last = f.End
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func (r *Redactor) label(rule string) string {
+	for _, c := range r.rules {
+		if c.name == rule {
+			return c.replace
 		}
-		return line
-	})
-	return out
+	}
+	return "[REDACTED]"
+}
+
+func matchesAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,72 @@
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var placeholderRE = regexp.MustCompile(`<SECRET_[0-9]+>`)
+
+// Vault maps reversible placeholders back to the values they mask.
+// It lives only in process memory and is never sent to a provider.
+type Vault struct {
+	byValue map[string]string
+	byToken map[string]string
+}
+
+// NewVault constructs an empty placeholder mapping.
+func NewVault() *Vault {
+	return &Vault{byValue: map[string]string{}, byToken: map[string]string{}}
+}
+
+// ScrubReversible masks findings with stable per-value placeholders recorded in v.
+// The same value always maps to the same placeholder within a vault.
+func (r *Redactor) ScrubReversible(s string, v *Vault) string {
+	if v == nil {
+		return r.Scrub(s)
+	}
+	return r.replace(s, func(f Finding) string {
+		return v.placeholder(f.Value)
+	})
+}
+
+func (v *Vault) placeholder(value string) string {
+	if token, ok := v.byValue[value]; ok {
+		return token
+	}
+	token := fmt.Sprintf("<SECRET_%d>", len(v.byValue)+1)
+	v.byValue[value] = token
+	v.byToken[token] = value
+	return token
+}
+
+// Restore substitutes known placeholders in s with their original values.
+// Unknown placeholders are left untouched.
+func (v *Vault) Restore(s string) string {
+	if v == nil || len(v.byToken) == 0 {
+		return s
+	}
+	return placeholderRE.ReplaceAllStringFunc(s, func(token string) string {
+		if value, ok := v.byToken[token]; ok {
+			return value
+		}
+		return token
+	})
+}
+
+// Referenced lists the known placeholders that appear in s.
+func (v *Vault) Referenced(s string) []string {
+	if v == nil || !strings.Contains(s, "<SECRET_") {
+		return nil
+	}
+	var out []string
+	seen := map[string]bool{}
+	for _, token := range placeholderRE.FindAllString(s, -1) {
+		if _, ok := v.byToken[token]; ok && !seen[token] {
+			seen[token] = true
+			out = append(out, token)
+		}
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package shell
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// answers is where prompts read replies from. It is the controlling terminal rather than
+// stdin, because stdin may already carry data for the command (cmd | ai ask --run) and
+// must not also answer its confirmations. If /dev/tty cannot be opened, stdin is used only
+// when it is a terminal; otherwise every prompt reads end of input and is declined. A single
+// reader is shared so input buffered by one prompt is not lost to the next.
+var answers struct {
+	once sync.Once
+	r    *bufio.Reader
+}
+
+func answerReader() *bufio.Reader {
+	answers.once.Do(func() {
+		if tty, err := os.Open("/dev/tty"); err == nil {
+			answers.r = bufio.NewReader(tty)
+			return
+		}
+		if term.IsTerminal(int(os.Stdin.Fd())) {
+			answers.r = bufio.NewReader(os.Stdin)
+			return
+		}
+		answers.r = bufio.NewReader(strings.NewReader(""))
+	})
+	return answers.r
+}
+
+// Confirm reads a y/N answer; anything but y or yes means no.
+func Confirm() bool {
+	line, _ := answerReader().ReadString('\n')
+	line = strings.ToLower(strings.TrimSpace(line))
+	return line == "y" || line == "yes"
+}
+
+// ConfirmTyped reads a line and reports whether it exactly matches expected.
+func ConfirmTyped(expected string) bool {
+	line, _ := answerReader().ReadString('\n')
+	return strings.TrimSpace(line) == expected
+}
+
+// ReadLine reads one line without its trailing newline. It fails only when the input is
+// closed before anything was typed.
+func ReadLine() (string, error) {
+	line, err := answerReader().ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Choice is the answer to a run/edit/copy confirmation prompt.
+type Choice int
+
+const (
+	ChoiceNo Choice = iota
+	ChoiceYes
+	ChoiceEdit
+	ChoiceCopy
+)
+
+// Choose reads a [y/N/e/c] answer; anything unrecognised means no.
+func Choose() Choice {
+	line, _ := answerReader().ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return ChoiceYes
+	case "e", "edit":
+		return ChoiceEdit
+	case "c", "copy":
+		return ChoiceCopy
+	}
+	return ChoiceNo
+}
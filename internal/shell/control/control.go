@@ -0,0 +1,170 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// Op names an action the session performs on behalf of a client.
+type Op string
+
+const (
+	// OpRun types the text into the interactive shell followed by Enter.
+	OpRun Op = "run"
+	// OpType types the text into the shell's line editor without submitting it.
+	OpType Op = "type"
+	// OpCredential returns the credential named by the text, held by the session so it
+	// never has to be in the interactive shell's environment. It is only answered for
+	// clients running the aish executable itself; see checkPeer.
+	OpCredential Op = "credential"
+)
+
+// Request is a single JSON line sent by a client over the control socket.
+type Request struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// Response acknowledges a Request.
+type Response struct {
+	OK    bool   `json:"ok"`
+	Value string `json:"value,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Handler performs a validated request and returns an optional value. Errors are reported back to the client.
+type Handler func(Request) (string, error)
+
+// Server accepts control requests on a unix socket owned by the session.
+type Server struct {
+	ln   net.Listener
+	path string
+}
+
+// Listen creates the socket at path, readable only by the current user, and serves requests until Close.
+func Listen(path string, handle Handler) (*Server, error) {
+	_ = os.Remove(path)
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("control socket: %w", err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("control socket: %w", err)
+	}
+
+	s := &Server{ln: ln, path: path}
+	go s.serve(handle)
+	return s, nil
+}
+
+// Close stops accepting requests and removes the socket file.
+func (s *Server) Close() error {
+	err := s.ln.Close()
+	_ = os.Remove(s.path)
+	return err
+}
+
+func (s *Server) serve(handle Handler) {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go serveConn(conn, handle)
+	}
+}
+
+func serveConn(conn net.Conn, handle Handler) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	resp := Response{OK: true}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		resp = Response{Error: "read request: " + err.Error()}
+	} else {
+		var req Request
+		if err := json.Unmarshal(line, &req); err != nil {
+			resp = Response{Error: "invalid request: " + err.Error()}
+		} else if err := validate(req); err != nil {
+			resp = Response{Error: err.Error()}
+		} else if err := authorize(conn, req); err != nil {
+			resp = Response{Error: err.Error()}
+		} else if value, err := handle(req); err != nil {
+			resp = Response{Error: err.Error()}
+		} else {
+			resp.Value = value
+		}
+	}
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+func validate(req Request) error {
+	switch req.Op {
+	case OpRun, OpType, OpCredential:
+	default:
+		return fmt.Errorf("unknown op %q", req.Op)
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		return errors.New("empty text")
+	}
+	if strings.ContainsAny(req.Text, "\r\n") {
+		return errors.New("text must be a single line")
+	}
+	// The text is typed into the pty, where control characters act as keys: Ctrl-O or
+	// Ctrl-J would submit a line sent with OpType, ESC sequences would drive the editor.
+	if strings.ContainsFunc(req.Text, func(r rune) bool { return r < 0x20 || r == 0x7f }) {
+		return errors.New("text must not contain control characters")
+	}
+	return nil
+}
+
+// authorize restricts OpCredential to aish's own processes. Typing into the shell is not
+// restricted: a client running as the same user can already run those commands itself.
+func authorize(conn net.Conn, req Request) error {
+	if req.Op != OpCredential {
+		return nil
+	}
+	if err := checkPeer(conn); err != nil {
+		return fmt.Errorf("credential refused: %w", err)
+	}
+	return nil
+}
+
+// Send delivers req to the session listening on socket and waits for its acknowledgement.
+func Send(socket string, req Request) error {
+	_, err := Fetch(socket, req)
+	return err
+}
+
+// Fetch delivers req to the session listening on socket and returns the value it answered with.
+func Fetch(socket string, req Request) (string, error) {
+	if strings.TrimSpace(socket) == "" {
+		return "", errors.New("no control socket; run from an aish session")
+	}
+	conn, err := net.DialTimeout("unix", socket, 2*time.Second)
+	if err != nil {
+		return "", fmt.Errorf("control socket: %w", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return "", fmt.Errorf("control socket: %w", err)
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return "", fmt.Errorf("control socket: %w", err)
+	}
+	if !resp.OK {
+		return "", fmt.Errorf("control socket: %s", resp.Error)
+	}
+	return resp.Value, nil
+}
@@ -0,0 +1,55 @@
+//go:build linux
+
+package control
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// checkPeer accepts a client only if it runs as the current user from the same executable
+// as this process, so other programs started in the shell cannot read the keys the session
+// holds by connecting to the socket named in AISH_CONTROL_SOCKET. It does not cover keys
+// cached in the kernel keyring, which every process of the user can read.
+func checkPeer(conn net.Conn) error {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return errors.New("not a unix socket")
+	}
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var cred *unix.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return err
+	}
+	if credErr != nil {
+		return fmt.Errorf("peer credentials: %w", credErr)
+	}
+	if int(cred.Uid) != os.Getuid() {
+		return errors.New("peer runs as another user")
+	}
+
+	// Stat the /proc links rather than comparing paths, so a binary replaced on disk
+	// while the session runs still matches itself.
+	self, err := os.Stat("/proc/self/exe")
+	if err != nil {
+		return err
+	}
+	peer, err := os.Stat(fmt.Sprintf("/proc/%d/exe", cred.Pid))
+	if err != nil {
+		return fmt.Errorf("peer executable: %w", err)
+	}
+	if !os.SameFile(self, peer) {
+		return errors.New("peer is not aish")
+	}
+	return nil
+}
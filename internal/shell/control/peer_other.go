@@ -0,0 +1,14 @@
+//go:build !linux
+
+package control
+
+import (
+	"errors"
+	"net"
+)
+
+// checkPeer refuses every client: without SO_PEERCRED the caller cannot be identified, so
+// credentials are never served over the socket and ai falls back to the credentials file.
+func checkPeer(net.Conn) error {
+	return errors.New("credential requests are not supported on this platform")
+}
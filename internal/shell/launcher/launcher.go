@@ -62,8 +62,9 @@ func (l *Launcher) Run() error {
 		_ = f.Close()
 	}
 
-	snippetsPath := filepath.Join(aishAppData, "snippets.yaml")
+	snippetsPath := l.cfg.Paths.SnippetsFile
 	if _, err := os.Stat(snippetsPath); os.IsNotExist(err) {
+		_ = os.MkdirAll(filepath.Dir(snippetsPath), 0o700)
 		if f, err := os.OpenFile(snippetsPath, os.O_CREATE, 0o600); err == nil {
 			_ = f.Close()
 		}
@@ -72,6 +73,7 @@ func (l *Launcher) Run() error {
 	}
 
 	logPath := filepath.Join(sessionDir, "session.log")
+	controlPath := filepath.Join(sessionDir, "control.sock")
 
 	cmd, cleanup, err := prompt.BuildShellCommand(sh, shellName, exe)
 	if err != nil {
@@ -81,12 +83,28 @@ func (l *Launcher) Run() error {
 		defer cleanup()
 	}
 
+	// Only per-session paths are exported; everything else is resolved from the config
+	// layers on each invocation, so project files apply in the directory a command runs in.
 	cmd.Env = append(cmd.Env,
 		"AISH_SESSION_LOG="+logPath,
 		"AISH_HISTORY_FILE="+historyPath,
-		"AISH_SNIPPETS_FILE="+snippetsPath,
+		"AISH_CONTROL_SOCKET="+controlPath,
 	)
+	// AISH_HINTS only tells the prompt hook whether starting ai hint after a failure is worth
+	// a fork and a config resolve; the hint command still checks hints.enabled itself.
+	if l.cfg.Hints.Enabled {
+		cmd.Env = append(cmd.Env, "AISH_HINTS=1")
+	}
+
+	// Credentials are stripped from the shell's environment by BuildShellCommand and
+	// handed to aish processes over the control socket instead.
+	credentials := map[string]string{}
+	for _, k := range config.SecretKeys() {
+		if v := os.Getenv(k.Env); v != "" {
+			credentials[k.Name] = v
+		}
+	}
 
-	session := shellpty.New()
+	session := shellpty.New(controlPath, credentials)
 	return session.Run(cmd, logPath)
 }
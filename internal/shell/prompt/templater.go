@@ -5,7 +5,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+
+	"github.com/mr-gaber/ai-shell/internal/config"
 )
 
 func BuildShellCommand(sh, shellName, exe string) (*exec.Cmd, func(), error) {
@@ -18,7 +21,7 @@ func BuildShellCommand(sh, shellName, exe string) (*exec.Cmd, func(), error) {
 			return nil, nil, fmt.Errorf("make temp bash rc: %w", err)
 		}
 		cmd := exec.Command(sh, "--rcfile", rcPath, "-i")
-		cmd.Env = append(os.Environ(), aishEnv...)
+		cmd.Env = append(shellEnviron(), aishEnv...)
 		return cmd, cleanup, nil
 
 	case "zsh":
@@ -27,7 +30,7 @@ func BuildShellCommand(sh, shellName, exe string) (*exec.Cmd, func(), error) {
 			return nil, nil, fmt.Errorf("make temp zsh rc: %w", err)
 		}
 		cmd := exec.Command(sh, "-i")
-		cmd.Env = append(os.Environ(), append(aishEnv, "ZDOTDIR="+zdot)...)
+		cmd.Env = append(shellEnviron(), append(aishEnv, "ZDOTDIR="+zdot)...)
 		return cmd, cleanup, nil
 
 	default:
@@ -37,11 +40,21 @@ func BuildShellCommand(sh, shellName, exe string) (*exec.Cmd, func(), error) {
 		}
 		aishTag := "\\[\\e[1;36m\\][aish]\\[\\e[0m\\] "
 		cmd := exec.Command(sh)
-		cmd.Env = append(os.Environ(), append(aishEnv, "PS1="+aishTag+existingPS1)...)
+		cmd.Env = append(shellEnviron(), append(aishEnv, "PS1="+aishTag+existingPS1)...)
 		return cmd, nil, nil
 	}
 }
 
+// shellEnviron is the current environment without credentials: the interactive shell and
+// everything it starts never see API keys, which the session serves to aish on request.
+func shellEnviron() []string {
+	env := os.Environ()
+	for _, k := range config.SecretKeys() {
+		env = slices.DeleteFunc(env, func(kv string) bool { return strings.HasPrefix(kv, k.Env+"=") })
+	}
+	return env
+}
+
 func escapeShell(s string) string {
 	return strings.ReplaceAll(s, `"`, `\"`)
 }
@@ -64,9 +77,39 @@ export AISH=1
 PROMPT="%B%F{cyan}[aish]%f%b ${PROMPT}"
 
 # aish shell functions
+# ai runs with an eval file; approved builtin fixes (cd, export, source) written
+# there are sourced into this shell once the binary exits.
 if [[ -n "$AISH_EXE" ]]; then
-  function ai()   { "$AISH_EXE" __ai "$@"; }
+  function ai() {
+    local eval_file rc
+    eval_file=$(mktemp "${TMPDIR:-/tmp}/aish-eval.XXXXXX") || { "$AISH_EXE" __ai "$@"; return; }
+    AISH_EVAL_FILE="$eval_file" "$AISH_EXE" __ai "$@"
+    rc=$?
+    if [[ -s "$eval_file" ]]; then
+      source "$eval_file"
+      rc=$?
+    fi
+    rm -f "$eval_file"
+    return $rc
+  }
   function snip() { "$AISH_EXE" __snip "$@"; }
+  # commands chosen for editing are pushed onto the buffer of the next prompt
+  function __aish_set_buffer() { print -z -- "$1"; }
+
+  # Ctrl-G sends the command line to aish and replaces it with the suggestion;
+  # on an empty line it suggests a correction of the last failed command.
+  function __aish_complete_widget() {
+    local suggestion
+    zle -I
+    suggestion=$("$AISH_EXE" __ai complete -- "$BUFFER" </dev/null)
+    if [[ -n "$suggestion" ]]; then
+      BUFFER=$suggestion
+      CURSOR=${#BUFFER}
+    fi
+    zle reset-prompt
+  }
+  zle -N __aish_complete_widget
+  bindkey '^G' __aish_complete_widget
 fi
 
 # --- aish: quick git branch helper
@@ -98,6 +141,11 @@ __aish_log_cmd() {
       "$(__aish_json_escape "$cmd")" \
       "$ec" "$(__aish_json_escape "$git")" \
       >> "$AISH_HISTORY_FILE"
+    # background hint for failures, only when hints were enabled at launch or through
+    # AISH_AUTO_HINT since; the binary applies opt-in, debounce and ignore list
+    if [ "$ec" -ne 0 ] && [ -n "$AISH_EXE" ] && [ -n "$AISH_HINTS$AISH_AUTO_HINT" ]; then
+      ( "$AISH_EXE" __ai hint </dev/null >/dev/tty 2>&1 & )
+    fi
   fi
 }
 
@@ -130,9 +178,44 @@ if [ -z "$PS1" ]; then PS1="\u@\h:\w\$ "; fi
 PS1='\[\e[1;36m\][aish]\[\e[0m\] '"$PS1"
 
 # aish shell functions
+# ai runs with an eval file; approved builtin fixes (cd, export, source) written
+# there are sourced into this shell once the binary exits.
 if [ -n "$AISH_EXE" ]; then
-  ai()   { "$AISH_EXE" __ai "$@"; }
+  ai() {
+    local eval_file rc
+    eval_file=$(mktemp "${TMPDIR:-/tmp}/aish-eval.XXXXXX") || { "$AISH_EXE" __ai "$@"; return; }
+    AISH_EVAL_FILE="$eval_file" "$AISH_EXE" __ai "$@"
+    rc=$?
+    if [ -s "$eval_file" ]; then
+      . "$eval_file"
+      rc=$?
+    fi
+    rm -f "$eval_file"
+    return $rc
+  }
   snip() { "$AISH_EXE" __snip "$@"; }
+  # bash cannot prefill the next prompt from a function, so a command chosen for
+  # editing is kept and inserted with Ctrl-X Ctrl-A.
+  __aish_set_buffer() {
+    __aish_pending_line=$1
+    printf '[aish] Press Ctrl-X Ctrl-A to insert the command.\n' >&2
+  }
+  __aish_insert_pending() {
+    READLINE_LINE=$__aish_pending_line
+    READLINE_POINT=${#READLINE_LINE}
+  }
+  bind -x '"\C-x\C-a": __aish_insert_pending' 2>/dev/null
+
+  # Ctrl-G sends the command line to aish and replaces it with the suggestion;
+  # on an empty line it suggests a correction of the last failed command.
+  __aish_complete_line() {
+    local suggestion
+    suggestion=$("$AISH_EXE" __ai complete -- "$READLINE_LINE" </dev/null)
+    [ -n "$suggestion" ] || return 0
+    READLINE_LINE=$suggestion
+    READLINE_POINT=${#READLINE_LINE}
+  }
+  bind -x '"\C-g": __aish_complete_line' 2>/dev/null
 fi
 
 # --- aish: quick git branch helper (empty if not a repo)
@@ -166,6 +249,11 @@ __aish_log_cmd() {
       "$(__aish_json_escape "$cmd")" \
       "$ec" "$(__aish_json_escape "$git")" \
       >> "$AISH_HISTORY_FILE"
+    # background hint for failures, only when hints were enabled at launch or through
+    # AISH_AUTO_HINT since; the binary applies opt-in, debounce and ignore list
+    if [ "$ec" -ne 0 ] && [ -n "$AISH_EXE" ] && [ -n "$AISH_HINTS$AISH_AUTO_HINT" ]; then
+      ( "$AISH_EXE" __ai hint </dev/null >/dev/tty 2>&1 & )
+    fi
   fi
 }
 
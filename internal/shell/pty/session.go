@@ -7,16 +7,25 @@ import (
 	"os/exec"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/creack/pty"
+	"github.com/mr-gaber/ai-shell/internal/shell/control"
+	"golang.org/x/sys/unix"
 	"golang.org/x/term"
 )
 
 // Session wires stdio through a pseudo terminal and captures output to a log.
-type Session struct{}
+type Session struct {
+	controlPath string
+	credentials map[string]string
+}
 
-func New() *Session {
-	return &Session{}
+// New constructs a Session. When controlPath is set the session listens there for
+// requests to type commands into the interactive shell and serves credentials, keyed by
+// config key name, that were removed from the shell's environment.
+func New(controlPath string, credentials map[string]string) *Session {
+	return &Session{controlPath: controlPath, credentials: credentials}
 }
 
 func (s *Session) Run(cmd *exec.Cmd, logPath string) error {
@@ -26,6 +35,25 @@ func (s *Session) Run(cmd *exec.Cmd, logPath string) error {
 	}
 	defer func() { _ = ptmx.Close() }()
 
+	if s.controlPath != "" {
+		srv, err := control.Listen(s.controlPath, func(req control.Request) (string, error) {
+			if req.Op == control.OpCredential {
+				value, ok := s.credentials[req.Text]
+				if !ok {
+					return "", fmt.Errorf("no credential %q in this session", req.Text)
+				}
+				return value, nil
+			}
+			go inject(ptmx, cmd.Process.Pid, req)
+			return "", nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "aish: control channel unavailable:", err)
+		} else {
+			defer srv.Close()
+		}
+	}
+
 	resize := func() { _ = pty.InheritSize(os.Stdin, ptmx) }
 	ch := make(chan os.Signal, 1)
 	signal.Notify(ch, syscall.SIGWINCH)
@@ -62,3 +90,38 @@ func (s *Session) Run(cmd *exec.Cmd, logPath string) error {
 	}
 	return nil
 }
+
+// injectTimeout bounds how long a request waits for the shell to get the terminal back.
+const injectTimeout = 30 * time.Second
+
+// inject types the request into the shell once it is the foreground process group again,
+// i.e. after the aish command that sent the request has exited.
+func inject(ptmx *os.File, shellPID int, req control.Request) {
+	deadline := time.Now().Add(injectTimeout)
+	for !shellInForeground(ptmx, shellPID) {
+		if time.Now().After(deadline) {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	text := req.Text
+	if req.Op == control.OpRun {
+		text += "\r"
+	}
+	_, _ = io.WriteString(ptmx, text)
+}
+
+func shellInForeground(ptmx *os.File, shellPID int) bool {
+	rc, err := ptmx.SyscallConn()
+	if err != nil {
+		return false
+	}
+	pgrp := -1
+	_ = rc.Control(func(fd uintptr) {
+		if n, err := unix.IoctlGetInt(int(fd), unix.TIOCGPGRP); err == nil {
+			pgrp = n
+		}
+	})
+	return pgrp == shellPID
+}
@@ -1,8 +1,14 @@
 package runner
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
+
+	"github.com/mr-gaber/ai-shell/internal/utils"
 )
 
 // Run executes the provided command string inside the user's shell.
@@ -18,3 +24,53 @@ func Run(cmdline string) error {
 	cmd.Env = os.Environ()
 	return cmd.Run()
 }
+
+// Capture runs cmdline like Run, in dir when set, while also recording its combined output.
+// It returns the output and exit code; err is set only when the command could not be started.
+func Capture(cmdline, dir string) (string, int, error) {
+	sh := os.Getenv("SHELL")
+	if sh == "" {
+		sh = "/bin/bash"
+	}
+	var buf bytes.Buffer
+	cmd := exec.Command(sh, "-lc", cmdline)
+	cmd.Dir = dir
+	cmd.Stdout = io.MultiWriter(os.Stdout, &buf)
+	cmd.Stderr = io.MultiWriter(os.Stderr, &buf)
+	cmd.Stdin = os.Stdin
+	cmd.Env = os.Environ()
+
+	err := cmd.Run()
+	var ee *exec.ExitError
+	switch {
+	case err == nil:
+		return buf.String(), 0, nil
+	case errors.As(err, &ee):
+		return buf.String(), ee.ExitCode(), nil
+	default:
+		return buf.String(), -1, err
+	}
+}
+
+// RunInParent queues cmdline in the eval file that the interactive shell's ai function
+// sources after aish exits, so builtins like cd and export affect that shell.
+func RunInParent(cmdline, evalFile string) error {
+	if evalFile == "" {
+		return fmt.Errorf("no eval file available; run from an aish session")
+	}
+	f, err := os.OpenFile(evalFile, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open eval file: %w", err)
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintln(f, cmdline); err != nil {
+		return fmt.Errorf("write eval file: %w", err)
+	}
+	return nil
+}
+
+// SetBuffer asks the parent shell, via the eval file, to place cmdline in its editing buffer
+// instead of running it. The rc defines __aish_set_buffer for each supported shell.
+func SetBuffer(cmdline, evalFile string) error {
+	return RunInParent("__aish_set_buffer "+utils.ShellQuote(cmdline), evalFile)
+}
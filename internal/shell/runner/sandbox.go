@@ -0,0 +1,65 @@
+package runner
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Report summarises the filesystem effects of a sandboxed dry-run relative to the working directory.
+type Report struct {
+	Dir      string
+	ExitCode int
+	Created  []string
+	Modified []string
+	Deleted  []string
+}
+
+// Empty reports whether the dry-run left no visible changes.
+func (r *Report) Empty() bool {
+	return len(r.Created) == 0 && len(r.Modified) == 0 && len(r.Deleted) == 0
+}
+
+// diffUpper walks an overlay upper directory and classifies each entry against lower.
+func diffUpper(lower, upper string) (*Report, error) {
+	r := &Report{Dir: lower}
+	err := filepath.WalkDir(upper, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(upper, path)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		_, statErr := os.Lstat(filepath.Join(lower, rel))
+		existed := statErr == nil
+
+		switch {
+		case isWhiteout(info):
+			r.Deleted = append(r.Deleted, rel)
+		case d.IsDir():
+			if !existed {
+				r.Created = append(r.Created, rel+string(filepath.Separator))
+			}
+		case existed:
+			r.Modified = append(r.Modified, rel)
+		default:
+			r.Created = append(r.Created, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(r.Created)
+	sort.Strings(r.Modified)
+	sort.Strings(r.Deleted)
+	return r, nil
+}
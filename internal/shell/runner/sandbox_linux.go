@@ -0,0 +1,219 @@
+//go:build linux
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// DryRun executes cmdline in a throwaway sandbox: user, mount, PID and IPC namespaces with no
+// network, a read-only view of the filesystem, and a copy-on-write overlay on the working
+// directory. Runtime directories holding sockets (/run, $XDG_RUNTIME_DIR and the aish session
+// directory with its control socket) are hidden, and AISH_* variables are not passed on, so the
+// command cannot reach the live shell, other services or host processes.
+// It reports which files under the working directory the command created, modified or deleted.
+func DryRun(cmdline string) (*Report, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("sandbox: cannot resolve working directory: %w", err)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("sandbox: cannot find own path: %w", err)
+	}
+
+	tmp, err := os.MkdirTemp("", "aish-sandbox-*")
+	if err != nil {
+		return nil, fmt.Errorf("sandbox: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	upper := filepath.Join(tmp, "upper")
+	work := filepath.Join(tmp, "work")
+	for _, dir := range []string{upper, work} {
+		if err := os.Mkdir(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("sandbox: %w", err)
+		}
+	}
+
+	hidden := hiddenDirs()
+	for _, dir := range hidden {
+		if isWithin(cwd, dir) {
+			return nil, fmt.Errorf("sandbox: cannot dry-run inside %s", dir)
+		}
+	}
+
+	cmd := exec.Command(exe, append([]string{"__sandbox", cwd, upper, work, cmdline}, hidden...)...)
+	cmd.Stdin = nil
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = sandboxEnv()
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWNET |
+			syscall.CLONE_NEWPID | syscall.CLONE_NEWIPC,
+		UidMappings: []syscall.SysProcIDMap{
+			{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1},
+		},
+		GidMappings: []syscall.SysProcIDMap{
+			{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1},
+		},
+		GidMappingsEnableSetgroups: false,
+		AmbientCaps:                []uintptr{unix.CAP_SYS_ADMIN},
+	}
+
+	exitCode := 0
+	if err := cmd.Run(); err != nil {
+		var ee *exec.ExitError
+		if !errors.As(err, &ee) {
+			return nil, fmt.Errorf("sandbox: cannot start (are unprivileged user namespaces enabled?): %w", err)
+		}
+		exitCode = ee.ExitCode()
+		if exitCode == sandboxSetupFailed {
+			return nil, fmt.Errorf("sandbox: setup failed")
+		}
+	}
+
+	report, err := diffUpper(cwd, upper)
+	if err != nil {
+		return nil, fmt.Errorf("sandbox: cannot inspect changes: %w", err)
+	}
+	report.ExitCode = exitCode
+	return report, nil
+}
+
+// hiddenDirs lists the existing directories the sandbox covers with an empty tmpfs.
+func hiddenDirs() []string {
+	candidates := []string{"/run", "/var/run", os.Getenv("XDG_RUNTIME_DIR")}
+	for _, env := range []string{"AISH_CONTROL_SOCKET", "AISH_SESSION_LOG", "AISH_HISTORY_FILE"} {
+		if p := os.Getenv(env); p != "" {
+			candidates = append(candidates, filepath.Dir(p))
+		}
+	}
+
+	var dirs []string
+	for _, c := range candidates {
+		if c == "" {
+			continue
+		}
+		// /var/run is usually a link to /run; mount over the real directory.
+		dir, err := filepath.EvalSymlinks(c)
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	// Keep only the outermost directories: once /run is covered, /run/user/1000 is gone.
+	slices.Sort(dirs)
+	var outer []string
+	for _, dir := range dirs {
+		if len(outer) == 0 || !isWithin(dir, outer[len(outer)-1]) {
+			outer = append(outer, dir)
+		}
+	}
+	return outer
+}
+
+// sandboxEnv is the environment without aish's own variables, which point at the session.
+func sandboxEnv() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "AISH_") {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+// sandboxSetupFailed is the exit status the child uses when it could not build the sandbox.
+const sandboxSetupFailed = 125
+
+// SandboxChild runs inside the namespaces created by DryRun. It expects
+// <dir> <upper> <work> <cmdline> [hidden...], mounts the sandbox and replaces itself with the
+// user's shell.
+func SandboxChild(args []string) int {
+	if len(args) < 4 {
+		fmt.Fprintln(os.Stderr, "aish: __sandbox expects <dir> <upper> <work> <cmdline> [hidden...]")
+		return sandboxSetupFailed
+	}
+	dir, upper, work, cmdline := args[0], args[1], args[2], args[3]
+
+	if err := setupSandbox(dir, upper, work, args[4:]); err != nil {
+		fmt.Fprintln(os.Stderr, "aish: sandbox:", err)
+		return sandboxSetupFailed
+	}
+
+	sh := os.Getenv("SHELL")
+	if sh == "" {
+		sh = "/bin/bash"
+	}
+	// Drop the capability used for mounting so the command runs with ordinary privileges.
+	_ = unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0)
+	err := syscall.Exec(sh, []string{sh, "-c", cmdline}, os.Environ())
+	fmt.Fprintln(os.Stderr, "aish: sandbox exec:", err)
+	return sandboxSetupFailed
+}
+
+func setupSandbox(dir, upper, work string, hidden []string) error {
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+
+	// A /proc for the new PID namespace, so only the sandbox's own processes are visible.
+	if err := unix.Mount("proc", "/proc", "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("mount /proc: %w", err)
+	}
+	for _, h := range hidden {
+		if err := unix.Mount("tmpfs", h, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=0755"); err != nil {
+			return fmt.Errorf("hide %s: %w", h, err)
+		}
+	}
+
+	opts := fmt.Sprintf("lowerdir=%s,upperdir=%s,workdir=%s", dir, upper, work)
+	if err := unix.Mount("overlay", dir, "overlay", 0, opts+",userxattr"); err != nil {
+		if err := unix.Mount("overlay", dir, "overlay", 0, opts); err != nil {
+			return fmt.Errorf("mount overlay on %s: %w", dir, err)
+		}
+	}
+
+	// Everything outside the working directory becomes read-only, except a private temp dir.
+	if err := unix.MountSetattr(-1, "/", unix.AT_RECURSIVE, &unix.MountAttr{Attr_set: unix.MOUNT_ATTR_RDONLY}); err != nil {
+		return fmt.Errorf("make filesystem read-only: %w", err)
+	}
+	if err := unix.MountSetattr(-1, dir, 0, &unix.MountAttr{Attr_clr: unix.MOUNT_ATTR_RDONLY}); err != nil {
+		return fmt.Errorf("make %s writable: %w", dir, err)
+	}
+	if tmp := os.TempDir(); !isWithin(dir, tmp) {
+		if err := unix.Mount("tmpfs", tmp, "tmpfs", 0, "mode=1777"); err != nil {
+			return fmt.Errorf("mount private %s: %w", tmp, err)
+		}
+	}
+
+	return os.Chdir(dir)
+}
+
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// isWhiteout reports whether an overlay upper entry marks a deleted file.
+func isWhiteout(info fs.FileInfo) bool {
+	if info.Mode()&fs.ModeCharDevice == 0 {
+		return false
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	return ok && st.Rdev == 0
+}
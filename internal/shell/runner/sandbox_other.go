@@ -0,0 +1,25 @@
+//go:build !linux
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// DryRun is only available on Linux, where namespaces and overlayfs back the sandbox.
+func DryRun(cmdline string) (*Report, error) {
+	return nil, errors.New("sandbox: dry-run is only supported on Linux")
+}
+
+// SandboxChild is only available on Linux.
+func SandboxChild(args []string) int {
+	fmt.Fprintln(os.Stderr, "aish: sandbox is only supported on Linux")
+	return 125
+}
+
+func isWhiteout(info fs.FileInfo) bool {
+	return false
+}
@@ -2,20 +2,74 @@ package model
 
 import "time"
 
-// Step models a single operation in a snippet, either shell string or exec argv.
+// Step models a single operation in a snippet, either shell string or exec argv, and how
+// it runs: a condition, a timeout, retries and whether a failure stops the snippet.
 type Step struct {
-	Cmd  string   `yaml:"cmd"`
-	Exec []string `yaml:"exec"`
+	Cmd  string   `yaml:"cmd" json:"cmd,omitempty"`
+	Exec []string `yaml:"exec" json:"exec,omitempty"`
+	// When is a condition on earlier exit codes or env vars, e.g. "exit != 0" or "env.CI"; see service.Run.
+	When string `yaml:"when,omitempty" json:"when,omitempty"`
+	// ContinueOnError records a failure and goes on with the next step.
+	ContinueOnError bool `yaml:"continue_on_error,omitempty" json:"continue_on_error,omitempty"`
+	// Retries re-runs a failing step, waiting Backoff (default 1s) and doubling it each time.
+	Retries int    `yaml:"retries,omitempty" json:"retries,omitempty"`
+	Backoff string `yaml:"backoff,omitempty" json:"backoff,omitempty"`
+	// Timeout kills an attempt that runs longer, e.g. "30s".
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// HasFlow reports whether the step uses any option beyond running once and stopping on failure.
+func (s Step) HasFlow() bool {
+	return s.When != "" || s.ContinueOnError || s.Retries > 0 || s.Timeout != ""
+}
+
+// Param types. ParamString is the default when a placeholder has no type.
+const (
+	ParamString = "string"
+	ParamInt    = "int"
+	ParamPath   = "path"
+	ParamEnum   = "enum"
+	// ParamRaw values are inserted into shell steps without quoting, e.g. for a list of flags.
+	ParamRaw = "raw"
+)
+
+// Param describes one [[placeholder]] of a snippet. Default is nil when the value is
+// required; Choices lists the allowed values of an enum.
+type Param struct {
+	Name        string   `yaml:"name" json:"name"`
+	Type        string   `yaml:"type,omitempty" json:"type"`
+	Default     *string  `yaml:"default,omitempty" json:"default,omitempty"`
+	Choices     []string `yaml:"choices,omitempty" json:"choices,omitempty"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+// Required reports whether a value must be supplied for the param.
+func (p Param) Required() bool {
+	return p.Default == nil
 }
 
 // Snippet captures the serialized representation of a snippet entry.
 type Snippet struct {
-	Steps     []Step     `yaml:"steps"`
-	Notes     string     `yaml:"notes,omitempty"`
-	Tags      []string   `yaml:"tags,omitempty"`
-	CreatedAt *time.Time `yaml:"created_at,omitempty"`
-	UpdatedAt *time.Time `yaml:"updated_at,omitempty"`
-	Runs      int        `yaml:"runs,omitempty"`
-	Vars      []string   `yaml:"vars,omitempty"`
-	VarsCount int        `yaml:"vars_count,omitempty"`
+	Steps     []Step     `yaml:"steps" json:"steps"`
+	Notes     string     `yaml:"notes,omitempty" json:"notes,omitempty"`
+	Tags      []string   `yaml:"tags,omitempty" json:"tags,omitempty"`
+	CreatedAt *time.Time `yaml:"created_at,omitempty" json:"created_at,omitempty"`
+	UpdatedAt *time.Time `yaml:"updated_at,omitempty" json:"updated_at,omitempty"`
+	Runs      int        `yaml:"runs,omitempty" json:"runs"`
+	Vars      []string   `yaml:"vars,omitempty" json:"vars,omitempty"`
+	VarsCount int        `yaml:"vars_count,omitempty" json:"vars_count"`
+	Params    []Param    `yaml:"params,omitempty" json:"params,omitempty"`
+}
+
+// ParamList returns the snippet's params. Snippets saved before params existed only list
+// Vars, which are treated as required strings.
+func (s Snippet) ParamList() []Param {
+	if len(s.Params) > 0 {
+		return s.Params
+	}
+	out := make([]Param, 0, len(s.Vars))
+	for _, v := range s.Vars {
+		out = append(out, Param{Name: v, Type: ParamString})
+	}
+	return out
 }
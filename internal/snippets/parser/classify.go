@@ -44,6 +44,10 @@ func looksShelly(s string) bool {
 	if strings.HasPrefix(s, "~") || strings.Contains(s, " ~/") {
 		return true
 	}
+	// A comment is left to the shell; as exec arguments its words would be passed on.
+	if strings.HasPrefix(s, "#") || strings.Contains(s, " #") || strings.Contains(s, "\t#") {
+		return true
+	}
 
 	return false
 }
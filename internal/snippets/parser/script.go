@@ -5,6 +5,7 @@ import (
 	"sort"
 
 	"github.com/mr-gaber/ai-shell/internal/snippets/model"
+	"github.com/mr-gaber/ai-shell/internal/snippets/template"
 )
 
 type stepKind int
@@ -18,20 +19,24 @@ const (
 type Script struct {
 	Steps    []model.Step
 	Vars     []string
+	Params   []model.Param
 	Warnings []string
 }
 
 // Build constructs a Script from normalized lines, classifying each line and tokenizing as needed.
 func Build(lines []string) (Script, error) {
 	if len(lines) == 0 {
-		return Script{}, fmt.Errorf("snip add: nothing to save (no lines)")
+		return Script{}, fmt.Errorf("nothing to save (no lines)")
 	}
 
-	warnings := make([]string, 0)
 	steps := make([]model.Step, 0, len(lines))
 
 	vars := collectVars(lines)
 	sort.Strings(vars)
+	params, warnings, err := collectParams(lines)
+	if err != nil {
+		return Script{}, err
+	}
 
 	for i, ln := range lines {
 		if looksLikeSplitSingleCommand(ln) {
@@ -41,6 +46,9 @@ func Build(lines []string) (Script, error) {
 
 		switch classify(ln) {
 		case stepCmd:
+			if err := template.Parse(ln).Check(); err != nil {
+				return Script{}, fmt.Errorf("line %d: %w", i+1, err)
+			}
 			steps = append(steps, model.Step{Cmd: ln})
 		case stepExec:
 			argv, err := tokenizeExec(ln)
@@ -55,8 +63,8 @@ func Build(lines []string) (Script, error) {
 	}
 
 	if len(steps) == 0 {
-		return Script{}, fmt.Errorf("snip add: nothing to save (no valid steps)")
+		return Script{}, fmt.Errorf("nothing to save (no valid steps)")
 	}
 
-	return Script{Steps: steps, Vars: vars, Warnings: warnings}, nil
+	return Script{Steps: steps, Vars: vars, Params: params, Warnings: warnings}, nil
 }
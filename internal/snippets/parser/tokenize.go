@@ -47,10 +47,10 @@ func tokenizeExec(line string) ([]string, error) {
 		cur = append(cur, '\\')
 	}
 	if inSingle {
-		return nil, fmt.Errorf("snip add: unmatched single quote")
+		return nil, fmt.Errorf("unmatched single quote")
 	}
 	if inDouble {
-		return nil, fmt.Errorf("snip add: unmatched double quote")
+		return nil, fmt.Errorf("unmatched double quote")
 	}
 
 	flush()
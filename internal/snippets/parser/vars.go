@@ -1,12 +1,76 @@
 package parser
 
 import (
-	"regexp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 
 	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/mr-gaber/ai-shell/internal/snippets/model"
+	"github.com/mr-gaber/ai-shell/internal/snippets/template"
 )
 
-var rxBrackets = regexp.MustCompile(`\[\[([A-Za-z_][A-Za-z0-9_]*)\]\]`)
+func toParam(p template.Placeholder) (model.Param, error) {
+	param := model.Param{Name: p.Name, Type: model.ParamString}
+	if p.Type != nil {
+		if err := ParseType(&param, *p.Type); err != nil {
+			return model.Param{}, err
+		}
+	}
+	if p.Default != nil {
+		if err := ValidateValue(param, *p.Default); err != nil {
+			return model.Param{}, fmt.Errorf("default of %s: %w", p.Name, err)
+		}
+		def := *p.Default
+		param.Default = &def
+	}
+	return param, nil
+}
+
+// ParseType sets p's type from a placeholder type spec: int, path, string, raw or a,b,c choices.
+func ParseType(p *model.Param, spec string) error {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "", model.ParamString:
+		p.Type = model.ParamString
+	case model.ParamInt, model.ParamPath, model.ParamRaw:
+		p.Type = spec
+	default:
+		if !strings.Contains(spec, ",") {
+			return fmt.Errorf("unknown type %q for %s (use int, path, string, raw or a,b,c choices)", spec, p.Name)
+		}
+		p.Type = model.ParamEnum
+		for _, c := range strings.Split(spec, ",") {
+			if c = strings.TrimSpace(c); c != "" && !slices.Contains(p.Choices, c) {
+				p.Choices = append(p.Choices, c)
+			}
+		}
+		if len(p.Choices) < 2 {
+			return fmt.Errorf("%s needs at least two choices", p.Name)
+		}
+	}
+	return nil
+}
+
+// ValidateValue checks that v is acceptable for the param's type.
+func ValidateValue(p model.Param, v string) error {
+	switch p.Type {
+	case model.ParamInt:
+		if _, err := strconv.Atoi(strings.TrimSpace(v)); err != nil {
+			return fmt.Errorf("%s must be an integer, got %q", p.Name, v)
+		}
+	case model.ParamPath:
+		if strings.TrimSpace(v) == "" {
+			return fmt.Errorf("%s must be a path", p.Name)
+		}
+	case model.ParamEnum:
+		if !slices.Contains(p.Choices, v) {
+			return fmt.Errorf("%s must be one of %s, got %q", p.Name, strings.Join(p.Choices, ", "), v)
+		}
+	}
+	return nil
+}
 
 func detectVarsInString(s string) []string {
 	if s == "" {
@@ -14,15 +78,8 @@ func detectVarsInString(s string) []string {
 	}
 
 	vars := []string{}
-
-	for _, m := range rxBrackets.FindAllStringSubmatchIndex(s, -1) {
-		start := m[0]
-		nameStart := m[2]
-		nameEnd := m[3]
-		if start > 0 && s[start-1] == '\\' {
-			continue
-		}
-		vars = append(vars, s[nameStart:nameEnd])
+	for _, p := range template.Find(s) {
+		vars = append(vars, p.Name)
 	}
 
 	return vars
@@ -43,3 +100,43 @@ func collectVars(lines []string) []string {
 
 	return set.ToSlice()
 }
+
+// collectParams returns the params declared across lines in order of first appearance. The
+// first occurrence that gives a type or default defines it; later conflicting ones are reported.
+func collectParams(lines []string) ([]model.Param, []string, error) {
+	var params []model.Param
+	var warnings []string
+	declared := map[string]bool{}
+	index := map[string]int{}
+
+	for _, line := range lines {
+		for _, p := range template.Find(line) {
+			param, err := toParam(p)
+			if err != nil {
+				return nil, nil, err
+			}
+			i, seen := index[p.Name]
+			switch {
+			case !seen:
+				index[p.Name] = len(params)
+				params = append(params, param)
+				declared[p.Name] = p.Declares()
+			case !p.Declares():
+				// A bare [[name]] reuses whatever was declared elsewhere.
+			case !declared[p.Name]:
+				params[i] = param
+				declared[p.Name] = true
+			case !sameDeclaration(params[i], param):
+				warnings = append(warnings, fmt.Sprintf("[aish] %s is declared more than once with different types or defaults; using the first", p.Name))
+			}
+		}
+	}
+	return params, warnings, nil
+}
+
+func sameDeclaration(a, b model.Param) bool {
+	if a.Type != b.Type || !slices.Equal(a.Choices, b.Choices) || (a.Default == nil) != (b.Default == nil) {
+		return false
+	}
+	return a.Default == nil || *a.Default == *b.Default
+}
@@ -0,0 +1,131 @@
+package record
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Var is a literal that appeared in more than one recorded command and became a placeholder
+// whose default is the recorded value, so the snippet still runs exactly as recorded.
+type Var struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Uses  int    `json:"uses"`
+}
+
+// literal matches words that can be replaced by a placeholder without changing how the shell
+// reads them: no quoting, expansion or operator characters, and nothing that would end the
+// placeholder's default early.
+var literal = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/@:+,-]*$`)
+
+var (
+	digits   = regexp.MustCompile(`^[0-9]+$`)
+	nonIdent = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+)
+
+// candidate is a literal being counted across commands.
+type candidate struct {
+	value string
+	flag  string
+	cmds  map[int]bool
+}
+
+// Generalize replaces literals that appear in at least two of cmds with [[vars]]. Command
+// names and flags are never replaced. A literal that follows a long flag (--namespace prod,
+// --namespace=prod) is named after the flag; others are called num, path or value.
+func Generalize(cmds []string) ([]string, []Var) {
+	var order []*candidate
+	byValue := map[string]*candidate{}
+	commandNames := map[string]bool{}
+
+	for i, cmd := range cmds {
+		words := strings.Fields(cmd)
+		start := true
+		for j, w := range words {
+			if start {
+				commandNames[w] = true
+				start = false
+				continue
+			}
+			switch w {
+			case "|", "||", "&&", ";", "&":
+				start = true
+				continue
+			}
+
+			value, flag := w, ""
+			if name, v, ok := strings.Cut(w, "="); ok && strings.HasPrefix(name, "--") {
+				value, flag = v, name
+			} else if j > 0 && strings.HasPrefix(words[j-1], "--") && !strings.Contains(words[j-1], "=") {
+				flag = words[j-1]
+			}
+			if len(value) < 2 || !literal.MatchString(value) {
+				continue
+			}
+			c := byValue[value]
+			if c == nil {
+				c = &candidate{value: value, cmds: map[int]bool{}}
+				byValue[value] = c
+				order = append(order, c)
+			}
+			if c.flag == "" {
+				c.flag = flag
+			}
+			c.cmds[i] = true
+		}
+	}
+
+	out := append([]string(nil), cmds...)
+	var vars []Var
+	used := map[string]int{}
+	for _, c := range order {
+		if len(c.cmds) < 2 || commandNames[c.value] {
+			continue
+		}
+		name := uniqueName(varName(c), used)
+		placeholder := "[[" + name + ":" + c.value + "]]"
+		if digits.MatchString(c.value) {
+			placeholder = "[[" + name + "|int:" + c.value + "]]"
+		}
+
+		// A literal counts as a word when it stands alone or follows '='; a separator is
+		// consumed by each match, so repeat until adjacent occurrences are all replaced.
+		word := regexp.MustCompile(`(^|[\s=])` + regexp.QuoteMeta(c.value) + `($|[\s;|&)])`)
+		uses := 0
+		for i := range out {
+			for word.MatchString(out[i]) {
+				uses += len(word.FindAllStringIndex(out[i], -1))
+				out[i] = word.ReplaceAllString(out[i], "${1}"+placeholder+"${2}")
+			}
+		}
+		vars = append(vars, Var{Name: name, Value: c.value, Uses: uses})
+	}
+	return out, vars
+}
+
+func varName(c *candidate) string {
+	if c.flag != "" {
+		name := strings.Trim(nonIdent.ReplaceAllString(strings.TrimLeft(c.flag, "-"), "_"), "_")
+		if name != "" && !digits.MatchString(name[:1]) {
+			return name
+		}
+	}
+	switch {
+	case digits.MatchString(c.value):
+		return "num"
+	case strings.Contains(c.value, "/"):
+		return "path"
+	default:
+		return "value"
+	}
+}
+
+// uniqueName numbers repeated names: value, value2, value3.
+func uniqueName(name string, used map[string]int) string {
+	used[name]++
+	if used[name] == 1 {
+		return name
+	}
+	return fmt.Sprintf("%s%d", name, used[name])
+}
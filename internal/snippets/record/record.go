@@ -0,0 +1,92 @@
+// Package record turns a range of the session history into snippet text.
+//
+// snip record stores a marker with the current end of history.jsonl in the session
+// directory; snip stop reads the commands appended since then.
+package record
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mr-gaber/ai-shell/internal/session/history"
+)
+
+// Recording is an active snip record in one session.
+type Recording struct {
+	Name    string    `json:"name"`
+	Offset  int64     `json:"offset"`
+	Started time.Time `json:"started"`
+}
+
+// ErrNotRecording is returned by Current when no recording is active.
+var ErrNotRecording = errors.New("no recording in progress")
+
+// markerPath keeps the marker next to the history it refers to, so it ends with the session.
+func markerPath(historyFile string) string {
+	return filepath.Join(filepath.Dir(historyFile), "recording.json")
+}
+
+// Start begins recording into name from the current end of historyFile.
+func Start(historyFile, name string) (Recording, error) {
+	if cur, err := Current(historyFile); err == nil {
+		return Recording{}, fmt.Errorf("already recording %q", cur.Name)
+	} else if !errors.Is(err, ErrNotRecording) {
+		return Recording{}, err
+	}
+
+	offset, err := history.Offset(historyFile)
+	if err != nil {
+		return Recording{}, err
+	}
+	rec := Recording{Name: name, Offset: offset, Started: time.Now()}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return Recording{}, err
+	}
+	if err := os.WriteFile(markerPath(historyFile), b, 0o600); err != nil {
+		return Recording{}, fmt.Errorf("saving recording marker: %w", err)
+	}
+	return rec, nil
+}
+
+// Current returns the active recording, or ErrNotRecording.
+func Current(historyFile string) (Recording, error) {
+	b, err := os.ReadFile(markerPath(historyFile))
+	if os.IsNotExist(err) {
+		return Recording{}, ErrNotRecording
+	}
+	if err != nil {
+		return Recording{}, fmt.Errorf("reading recording marker: %w", err)
+	}
+	var rec Recording
+	if err := json.Unmarshal(b, &rec); err != nil || rec.Name == "" {
+		return Recording{}, fmt.Errorf("corrupt recording marker %s", markerPath(historyFile))
+	}
+	return rec, nil
+}
+
+// Clear ends the active recording without saving anything.
+func Clear(historyFile string) error {
+	if err := os.Remove(markerPath(historyFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Commands returns the successful user commands run since the recording started.
+func Commands(historyFile string, rec Recording) (cmds []string, skipped int, err error) {
+	entries, err := history.Since(historyFile, rec.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	kept, skipped := Usable(entries)
+	for _, e := range kept {
+		cmds = append(cmds, strings.TrimSpace(e.Cmd))
+	}
+	return cmds, skipped, nil
+}
@@ -0,0 +1,72 @@
+package record
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mr-gaber/ai-shell/internal/session/history"
+)
+
+// Usable keeps the entries that make sense as snippet steps. It drops aish helpers and
+// immediate repeats, which the prompt hook logs again when an empty line is entered, and
+// counts as skipped the failed and multi-line commands (the latter would split into
+// separate steps).
+func Usable(entries []history.Entry) (kept []history.Entry, skipped int) {
+	prev := ""
+	for _, e := range entries {
+		cmd := strings.TrimSpace(e.Cmd)
+		repeat := cmd == prev
+		prev = cmd
+		switch {
+		case history.IsHelper(cmd) || repeat:
+			continue
+		case e.Exit != 0 || strings.Contains(cmd, "\n"):
+			skipped++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	return kept, skipped
+}
+
+// Last returns the last n usable entries.
+func Last(entries []history.Entry, n int) []history.Entry {
+	kept, _ := Usable(entries)
+	if n < len(kept) {
+		kept = kept[len(kept)-n:]
+	}
+	return kept
+}
+
+// Seq returns the entries with sequence numbers from..to, skipping aish helpers. Failed
+// commands are kept, since they were picked explicitly; multi-line ones are an error.
+func Seq(entries []history.Entry, from, to int) ([]history.Entry, error) {
+	var out []history.Entry
+	helpers := 0
+	for _, e := range entries {
+		if e.Seq < from || e.Seq > to {
+			continue
+		}
+		if history.IsHelper(e.Cmd) {
+			helpers++
+			continue
+		}
+		if strings.Contains(strings.TrimSpace(e.Cmd), "\n") {
+			return nil, fmt.Errorf("command %d spans several lines and cannot be a single step", e.Seq)
+		}
+		out = append(out, e)
+	}
+	if len(out) == 0 {
+		switch {
+		case helpers > 0 && from == to:
+			return nil, fmt.Errorf("command %d is an ai/snip helper", from)
+		case helpers > 0:
+			return nil, fmt.Errorf("only ai/snip helpers in %d-%d", from, to)
+		case from == to:
+			return nil, fmt.Errorf("no command with sequence number %d", from)
+		default:
+			return nil, fmt.Errorf("no commands with sequence numbers %d-%d", from, to)
+		}
+	}
+	return out, nil
+}
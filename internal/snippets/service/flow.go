@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mr-gaber/ai-shell/internal/errs"
+	"github.com/mr-gaber/ai-shell/internal/snippets/model"
+)
+
+// StepStatus is the outcome of one step of a run.
+type StepStatus string
+
+const (
+	StepOK      StepStatus = "ok"
+	StepFailed  StepStatus = "failed"
+	StepTimeout StepStatus = "timeout"
+	StepSkipped StepStatus = "skipped"
+	StepNotRun  StepStatus = "not run"
+)
+
+// maxRetries bounds Step.Retries so a typo cannot keep a snippet running for hours.
+const maxRetries = 10
+
+// StepResult records how one step of a run went.
+type StepResult struct {
+	Step     int           `json:"step"`
+	Command  string        `json:"command"`
+	Status   StepStatus    `json:"status"`
+	Exit     int           `json:"exit"`
+	Attempts int           `json:"attempts"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// stepPlan is a step with its options parsed.
+type stepPlan struct {
+	step    model.Step
+	when    cond
+	timeout time.Duration
+	backoff time.Duration
+}
+
+// planSteps validates the flow options of every step.
+func planSteps(steps []model.Step) ([]stepPlan, error) {
+	plans := make([]stepPlan, len(steps))
+	for i, step := range steps {
+		fields := errs.WithFields(map[string]string{"line": fmt.Sprintf("%d", i+1)})
+		p := stepPlan{step: step, backoff: time.Second}
+		if step.When != "" {
+			c, err := parseWhen(step.When, i)
+			if err != nil {
+				return nil, errs.Wrap(err, "snip-step-when", fmt.Sprintf("[aish] step %d: invalid when %q: %v", i+1, step.When, err), fields)
+			}
+			p.when = c
+		}
+		if step.Retries < 0 || step.Retries > maxRetries {
+			return nil, errs.New("snip-step-retries", fmt.Sprintf("[aish] step %d: retries must be between 0 and %d", i+1, maxRetries), fields)
+		}
+		var err error
+		if p.timeout, err = positiveDuration(step.Timeout, 0); err != nil {
+			return nil, errs.Wrap(err, "snip-step-timeout", fmt.Sprintf("[aish] step %d: invalid timeout: %v", i+1, err), fields)
+		}
+		if p.backoff, err = positiveDuration(step.Backoff, time.Second); err != nil {
+			return nil, errs.Wrap(err, "snip-step-backoff", fmt.Sprintf("[aish] step %d: invalid backoff: %v", i+1, err), fields)
+		}
+		plans[i] = p
+	}
+	return plans, nil
+}
+
+func positiveDuration(s string, fallback time.Duration) (time.Duration, error) {
+	if s == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("must be positive, got %s", s)
+	}
+	return d, nil
+}
+
+// execute runs prepared steps in order, honouring each step's condition, retries, timeout
+// and continue_on_error. It returns a result for every step; the error describes the step
+// that stopped the run.
+func (s *Service) execute(steps []model.Step) ([]StepResult, error) {
+	plans, err := planSteps(steps)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]StepResult, len(steps))
+	for i, step := range steps {
+		results[i] = StepResult{Step: i + 1, Command: stepCommand(step), Status: StepNotRun, Exit: -1}
+	}
+
+	st := newFlowState(len(steps))
+	for i, p := range plans {
+		res := &results[i]
+		if p.when != nil && !p.when.eval(st) {
+			res.Status = StepSkipped
+			fmt.Fprintf(s.out, "[aish] step %d skipped (when %s)\n", i+1, p.step.When)
+			continue
+		}
+
+		start := time.Now()
+		var runErr error
+		for attempt := 0; attempt <= p.step.Retries; attempt++ {
+			if attempt > 0 {
+				wait := p.backoff << (attempt - 1)
+				fmt.Fprintf(s.out, "[aish] step %d failed; retrying in %s (attempt %d/%d)\n", i+1, wait, attempt+1, p.step.Retries+1)
+				time.Sleep(wait)
+			}
+			res.Attempts++
+			res.Exit, res.Status, runErr = s.runStep(p)
+			if res.Status == StepOK {
+				break
+			}
+		}
+		res.Duration = time.Since(start).Round(time.Millisecond)
+		st.record(i, res.Exit)
+
+		if res.Status == StepOK || p.step.ContinueOnError {
+			continue
+		}
+		code := "snip-step-cmd"
+		switch {
+		case res.Status == StepTimeout:
+			code = "snip-step-timeout"
+		case p.step.Cmd == "":
+			code = "snip-step-exec"
+		}
+		return results, errs.Wrap(runErr, code, "[aish] command failed", errs.WithFields(map[string]string{"line": fmt.Sprintf("%d", i+1), "command": res.Command}))
+	}
+	return results, nil
+}
+
+// runStep runs one attempt of a step and reports its exit code and status.
+func (s *Service) runStep(p stepPlan) (int, StepStatus, error) {
+	ctx := context.Background()
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	var cmd *exec.Cmd
+	if len(p.step.Cmd) > 0 {
+		fmt.Fprintln(s.out, p.step.Cmd)
+		cmd = exec.CommandContext(ctx, "/bin/sh", "-c", p.step.Cmd)
+	} else {
+		fmt.Fprintln(s.out, p.step.Exec)
+		cmd = exec.CommandContext(ctx, p.step.Exec[0], p.step.Exec[1:]...)
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, s.out, os.Stderr
+	if p.timeout > 0 {
+		// Kill the whole process group on timeout, not just the shell. A step with a timeout
+		// runs in its own group, so it cannot read from the terminal.
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) }
+		cmd.WaitDelay = 2 * time.Second
+	}
+
+	err := cmd.Run()
+	switch {
+	case err == nil:
+		return 0, StepOK, nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		// 124 is what timeout(1) exits with, so conditions can test for it.
+		return 124, StepTimeout, fmt.Errorf("timed out after %s", p.timeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if exitErr.ExitCode() >= 0 {
+			return exitErr.ExitCode(), StepFailed, err
+		}
+		// Killed by a signal: report 128+n like the shell does.
+		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			return 128 + int(ws.Signal()), StepFailed, err
+		}
+	}
+	return 127, StepFailed, err
+}
+
+func stepCommand(step model.Step) string {
+	if step.Cmd != "" {
+		return step.Cmd
+	}
+	return strings.Join(step.Exec, " ")
+}
@@ -2,9 +2,9 @@ package service
 
 import (
 	"fmt"
+	"io"
 	"maps"
 	"os"
-	"os/exec"
 	"slices"
 	"strings"
 	"time"
@@ -13,39 +13,71 @@ import (
 	"github.com/mr-gaber/ai-shell/internal/snippets/model"
 	"github.com/mr-gaber/ai-shell/internal/snippets/parser"
 	"github.com/mr-gaber/ai-shell/internal/snippets/store"
+	"github.com/mr-gaber/ai-shell/internal/snippets/template"
+	"github.com/mr-gaber/ai-shell/internal/utils"
 )
 
 // Service encapsulates snippet parsing and persistence workflows.
 type Service struct {
-	store *store.Store
+	store  *store.Store
+	guard  func(command string) error
+	out    io.Writer
+	prompt Prompter
 }
 
-func New(path string) (*Service, error) {
+// Prompter asks the user for the value of a param that was not given on the command line.
+type Prompter func(p model.Param) (string, error)
+
+// Option mutates optional attributes on the Service during construction.
+type Option func(*Service)
+
+// WithGuard installs a check every rendered step must pass before any step of a snippet runs.
+func WithGuard(guard func(command string) error) Option {
+	return func(s *Service) {
+		s.guard = guard
+	}
+}
+
+// WithOutput sends echoed steps and their stdout to w instead of os.Stdout.
+func WithOutput(w io.Writer) Option {
+	return func(s *Service) {
+		s.out = w
+	}
+}
+
+// WithPrompter asks for missing required params instead of failing with snip-missing-vars.
+func WithPrompter(prompt Prompter) Option {
+	return func(s *Service) {
+		s.prompt = prompt
+	}
+}
+
+func New(path string, opts ...Option) (*Service, error) {
 	if strings.TrimSpace(path) == "" {
 		return nil, errs.New("snip-no-path", "[aish] Cannot find snippets yaml file")
 	}
-	return &Service{store: store.New(path)}, nil
+	s := &Service{store: store.New(path), out: os.Stdout}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
 }
 
 func (s *Service) Add(name string, raw string, force bool) (bool, []string, error) {
 	if err := validateName(name); err != nil {
-		return false, nil, err
+		return false, nil, errs.Wrap(err, "snip-name", "snip add: "+err.Error())
 	}
 
 	if !force {
-		exists, err := s.store.Exists(name)
-		if err != nil {
-			return false, nil, errs.Wrap(err, "snip-store-exists", "failed to inspect snippets store")
-		}
-		if exists {
-			return false, nil, errs.New("snip-exists", fmt.Sprintf("[aish] snippet %q already exists. Use --force to overwrite", name))
+		if err := s.checkFree(name); err != nil {
+			return false, nil, err
 		}
 	}
 
 	lines := parser.Normalize(raw)
 	script, err := parser.Build(lines)
 	if err != nil {
-		return false, nil, errs.Wrap(err, "snip-parse", "failed to build snippet script")
+		return false, nil, errs.Wrap(err, "snip-parse", "failed to build snippet script: "+err.Error())
 	}
 
 	now := time.Now()
@@ -56,6 +88,7 @@ func (s *Service) Add(name string, raw string, force bool) (bool, []string, erro
 		Runs:      0,
 		Vars:      script.Vars,
 		VarsCount: len(script.Vars),
+		Params:    script.Params,
 	}
 
 	created, err := s.store.Create(name, snippet)
@@ -65,116 +98,268 @@ func (s *Service) Add(name string, raw string, force bool) (bool, []string, erro
 	return created, script.Warnings, nil
 }
 
-func (s *Service) Run(name string, vars []string) error {
+// Available reports an error unless name is a valid snippet name that is not taken yet.
+func (s *Service) Available(name string) error {
+	if err := validateName(name); err != nil {
+		return errs.Wrap(err, "snip-name", "[aish] "+err.Error())
+	}
+	return s.checkFree(name)
+}
+
+func (s *Service) checkFree(name string) error {
+	exists, err := s.store.Exists(name)
+	if err != nil {
+		return errs.Wrap(err, "snip-store-exists", "failed to inspect snippets store")
+	}
+	if exists {
+		return errs.New("snip-exists", fmt.Sprintf("[aish] snippet %q already exists. Use --force to overwrite", name))
+	}
+	return nil
+}
+
+// Prepare loads a snippet, renders vars into its steps and checks every step with the guard.
+// Values in shell command steps are quoted, so they cannot change the command's structure.
+// Nothing is executed.
+func (s *Service) Prepare(name string, vars []string) (model.Snippet, error) {
 	snip, err := s.store.GetOne(name)
 	if err != nil {
-		return errs.Wrap(err, "snip-missing", "failed to load snippet", errs.WithFields(map[string]string{"name": name}))
+		return model.Snippet{}, errs.Wrap(err, "snip-missing", "failed to load snippet", errs.WithFields(map[string]string{"name": name}))
+	}
+
+	values, err := s.resolveParams(snip, vars)
+	if err != nil {
+		return model.Snippet{}, err
+	}
+
+	raw := map[string]bool{}
+	for _, p := range snip.ParamList() {
+		raw[p.Name] = p.Type == model.ParamRaw
+	}
+	for i := range snip.Steps {
+		line := map[string]string{"line": fmt.Sprintf("%d", i+1)}
+		cmd, err := template.Parse(snip.Steps[i].Cmd).RenderShell(values, raw)
+		if err != nil {
+			return model.Snippet{}, errs.Wrap(err, "snip-missing-vars", "[aish] "+err.Error(), errs.WithFields(line))
+		}
+		snip.Steps[i].Cmd = cmd
+		for j, arg := range snip.Steps[i].Exec {
+			if snip.Steps[i].Exec[j], err = template.Parse(arg).Render(values); err != nil {
+				return model.Snippet{}, errs.Wrap(err, "snip-missing-vars", "[aish] "+err.Error(), errs.WithFields(line))
+			}
+		}
+	}
+
+	if _, err := planSteps(snip.Steps); err != nil {
+		return model.Snippet{}, err
+	}
+
+	if s.guard != nil {
+		for i, step := range snip.Steps {
+			command := step.Cmd
+			if command == "" {
+				command = strings.Join(step.Exec, " ")
+			}
+			if err := s.guard(command); err != nil {
+				return model.Snippet{}, errs.Wrap(err, "snip-step-blocked", err.Error(), errs.WithFields(map[string]string{"line": fmt.Sprintf("%d", i+1), "command": command}))
+			}
+		}
 	}
 
-	varsMap := map[string]string{}
+	return snip, nil
+}
 
+// resolveParams turns name=value arguments into a value for every param, falling back to
+// defaults and then, for required params, to the prompter. Each value is checked against its type.
+func (s *Service) resolveParams(snip model.Snippet, vars []string) (map[string]string, error) {
+	given := map[string]string{}
 	for _, v := range vars {
 		varString := strings.SplitN(v, "=", 2)
 		if len(varString) != 2 {
-			return errs.New("snip-var-format", fmt.Sprintf("[aish] invalid variable format %q (expected name=value)", v))
+			return nil, errs.New("snip-var-format", fmt.Sprintf("[aish] invalid variable format %q (expected name=value)", v))
 		}
 		varName := strings.TrimSpace(varString[0])
-		varValue := varString[1]
 		if varName == "" {
-			return errs.New("snip-var-name", fmt.Sprintf("[aish] invalid variable name in %q", v))
+			return nil, errs.New("snip-var-name", fmt.Sprintf("[aish] invalid variable name in %q", v))
 		}
-		varsMap[varName] = varValue
+		given[varName] = varString[1]
 	}
 
-	if len(vars) < snip.VarsCount {
-		missingVars := make([]string, 0, snip.VarsCount)
-		for _, v := range snip.Vars {
-			if _, ok := varsMap[v]; !ok {
-				missingVars = append(missingVars, v)
-			}
+	params := snip.ParamList()
+	var unknown []string
+	for name := range given {
+		if !slices.ContainsFunc(params, func(p model.Param) bool { return p.Name == name }) {
+			unknown = append(unknown, name)
 		}
-
-		return errs.New("snip-missing-vars", fmt.Sprintf("[aish] missing variables %s", strings.Join(missingVars, ",")))
+	}
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		known := make([]string, 0, len(params))
+		for _, p := range params {
+			known = append(known, p.Name)
+		}
+		hint := "it takes no variables"
+		if len(known) > 0 {
+			hint = "known: " + strings.Join(known, ", ")
+		}
+		return nil, errs.New("snip-var-unknown", fmt.Sprintf("[aish] unknown variables %s (%s)", strings.Join(unknown, ","), hint), errs.WithFields(map[string]string{"vars": strings.Join(unknown, ",")}))
 	}
 
-	for i := range snip.Steps {
-		cmd := snip.Steps[i].Cmd
-		exec := strings.Join(snip.Steps[i].Exec, "\x00")
-		for _, v := range snip.Vars {
-			placeholder := "[[" + v + "]]"
-			if strings.Contains(cmd, placeholder) {
-				cmd = strings.ReplaceAll(cmd, placeholder, varsMap[v])
-			}
-			if strings.Contains(exec, placeholder) {
-				exec = strings.ReplaceAll(exec, placeholder, varsMap[v])
+	values := map[string]string{}
+	var missing []string
+	for _, p := range params {
+		value, ok := given[p.Name]
+		switch {
+		case ok:
+		case !p.Required():
+			value = *p.Default
+		case s.prompt != nil:
+			v, err := s.prompt(p)
+			if err != nil {
+				return nil, errs.Wrap(err, "snip-var-prompt", "[aish] cannot read variable "+p.Name)
 			}
+			value = v
+		default:
+			missing = append(missing, p.Name)
+			continue
 		}
-		snip.Steps[i].Cmd = cmd
-		snip.Steps[i].Exec = strings.Split(exec, "\x00")
+		if err := parser.ValidateValue(p, value); err != nil {
+			return nil, errs.Wrap(err, "snip-var-invalid", "[aish] "+err.Error(), errs.WithFields(map[string]string{"var": p.Name, "value": value}))
+		}
+		if p.Type == model.ParamPath {
+			// Quoting would keep the shell from expanding a leading ~, so do it here.
+			value = expandHome(value)
+		}
+		values[p.Name] = value
+	}
+	if len(missing) > 0 {
+		return nil, errs.New("snip-missing-vars", fmt.Sprintf("[aish] missing variables %s", strings.Join(missing, ",")))
 	}
+	return values, nil
+}
 
-	for i, step := range snip.Steps {
-		if len(step.Cmd) > 0 {
-			fmt.Println(step.Cmd)
-			cmd := exec.Command("/bin/sh", "-c", step.Cmd)
-			cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
-			if err := cmd.Run(); err != nil {
-				return errs.Wrap(err, "snip-step-cmd", "[aish] command failed", errs.WithFields(map[string]string{"line": fmt.Sprintf("%d", i+1), "command": step.Cmd}))
-			}
-		} else {
-			fmt.Println(step.Exec)
-			cmd := exec.Command(step.Exec[0], step.Exec[1:]...)
-			cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
-			if err := cmd.Run(); err != nil {
-				return errs.Wrap(err, "snip-step-exec", "[aish] command failed", errs.WithFields(map[string]string{"line": fmt.Sprintf("%d", i+1), "command": strings.Join(step.Exec, " ")}))
-			}
+// SetParam updates the stored metadata of one param of a snippet.
+func (s *Service) SetParam(name string, param model.Param) error {
+	db, err := s.store.LoadAll()
+	if err != nil {
+		return errs.Wrap(err, "snip-list", "failed to read snippets")
+	}
+	snip, ok := db[name]
+	if !ok {
+		return errs.New("snip-missing", "failed to load snippet", errs.WithFields(map[string]string{"name": name}))
+	}
+
+	params := snip.ParamList()
+	i := slices.IndexFunc(params, func(p model.Param) bool { return p.Name == param.Name })
+	if i < 0 {
+		return errs.New("snip-param-unknown", fmt.Sprintf("[aish] snippet %q has no variable %q", name, param.Name), errs.WithFields(map[string]string{"name": name, "var": param.Name}))
+	}
+	if param.Default != nil {
+		if err := parser.ValidateValue(param, *param.Default); err != nil {
+			return errs.Wrap(err, "snip-var-invalid", "[aish] default: "+err.Error(), errs.WithFields(map[string]string{"var": param.Name}))
 		}
 	}
+	params[i] = param
 
+	now := time.Now()
+	snip.Params = params
+	snip.UpdatedAt = &now
+	db[name] = snip
+	if _, err := s.store.Save(db); err != nil {
+		return errs.Wrap(err, "snip-store-save", "failed to persist snippet")
+	}
 	return nil
 }
 
-func (s *Service) View(name string) error {
-	snip, err := s.store.GetOne(name)
+// Run executes a snippet's steps and returns the outcome of each, including skipped and
+// not-run steps, so callers can print a summary even when the run stopped early.
+func (s *Service) Run(name string, vars []string) ([]StepResult, error) {
+	snip, err := s.Prepare(name, vars)
 	if err != nil {
-		return errs.Wrap(err, "snip-missing", "failed to load snippet", errs.WithFields(map[string]string{"name": name}))
+		return nil, err
 	}
+	return s.execute(snip.Steps)
+}
 
-	fmt.Printf("snippet: %s\n", name)
-	if snip.CreatedAt != nil {
-		fmt.Printf("created: %s\n", snip.CreatedAt.Format(time.RFC3339))
-	}
-	if snip.VarsCount > 0 {
-		fmt.Printf("vars: %s (%d)\n", strings.Join(snip.Vars, ", "), snip.VarsCount)
+// Script renders a snippet as a single shell line whose steps stop at the first failure.
+// continue_on_error is kept; when, retries and timeout need aish to run the steps and are refused.
+// Trailing comments are dropped, as they would swallow the rest of the line.
+func (s *Service) Script(name string, vars []string) (string, error) {
+	snip, err := s.Prepare(name, vars)
+	if err != nil {
+		return "", err
 	}
-	if len(snip.Steps) > 0 {
-		fmt.Println("steps:")
-		for i, step := range snip.Steps {
-			if len(step.Cmd) > 0 {
-				fmt.Printf("[%d] $ %s\n", i, step.Cmd)
-			} else {
-				fmt.Printf("[%d] $ %s\n", i, strings.Join(step.Exec, " "))
-			}
 
+	parts := make([]string, 0, len(snip.Steps))
+	for i, step := range snip.Steps {
+		if step.When != "" || step.Retries > 0 || step.Timeout != "" {
+			return "", errs.New("snip-inject-flow", fmt.Sprintf("[aish] step %d uses when, retries or timeout, which cannot be injected; run it without --inject", i+1), errs.WithFields(map[string]string{"line": fmt.Sprintf("%d", i+1)}))
+		}
+		command := template.StripComment(step.Cmd)
+		if len(step.Cmd) == 0 {
+			quoted := make([]string, len(step.Exec))
+			for i, arg := range step.Exec {
+				quoted[i] = utils.ShellQuote(arg)
+			}
+			command = strings.Join(quoted, " ")
+		}
+		if strings.TrimSpace(command) == "" {
+			continue
 		}
+		if step.ContinueOnError {
+			command += " || true"
+		}
+		parts = append(parts, "{ "+command+"; }")
 	}
-
-	return nil
+	return strings.Join(parts, " && "), nil
 }
 
-func (s *Service) List() error {
-	snips, err := s.store.LoadAll()
+// SetStep replaces the flow options of step index (1-based), keeping its command.
+func (s *Service) SetStep(name string, index int, opts model.Step) (model.Step, error) {
+	db, err := s.store.LoadAll()
 	if err != nil {
-		return errs.Wrap(err, "snip-list", "failed to read snippets")
+		return model.Step{}, errs.Wrap(err, "snip-list", "failed to read snippets")
+	}
+	snip, ok := db[name]
+	if !ok {
+		return model.Step{}, errs.New("snip-missing", "failed to load snippet", errs.WithFields(map[string]string{"name": name}))
+	}
+	if index < 1 || index > len(snip.Steps) {
+		return model.Step{}, errs.New("snip-step-index", fmt.Sprintf("[aish] snippet %q has steps 1-%d", name, len(snip.Steps)), errs.WithFields(map[string]string{"name": name, "step": fmt.Sprintf("%d", index)}))
 	}
 
-	names := slices.Collect(maps.Keys(snips))
+	step := snip.Steps[index-1]
+	step.When, step.ContinueOnError, step.Retries, step.Backoff, step.Timeout = opts.When, opts.ContinueOnError, opts.Retries, opts.Backoff, opts.Timeout
+	snip.Steps[index-1] = step
+	if _, err := planSteps(snip.Steps); err != nil {
+		return model.Step{}, err
+	}
 
-	for i, name := range names {
-		fmt.Printf("[%d] %s\n", i, name)
+	now := time.Now()
+	snip.UpdatedAt = &now
+	db[name] = snip
+	if _, err := s.store.Save(db); err != nil {
+		return model.Step{}, errs.Wrap(err, "snip-store-save", "failed to persist snippet")
 	}
+	return step, nil
+}
 
-	return nil
+// View returns the stored snippet without substituting vars.
+func (s *Service) View(name string) (model.Snippet, error) {
+	snip, err := s.store.GetOne(name)
+	if err != nil {
+		return model.Snippet{}, errs.Wrap(err, "snip-missing", "failed to load snippet", errs.WithFields(map[string]string{"name": name}))
+	}
+	return snip, nil
+}
+
+// List returns the names of all stored snippets in sorted order.
+func (s *Service) List() ([]string, error) {
+	snips, err := s.store.LoadAll()
+	if err != nil {
+		return nil, errs.Wrap(err, "snip-list", "failed to read snippets")
+	}
+
+	return slices.Sorted(maps.Keys(snips)), nil
 }
 
 func (s *Service) Delete(name string) error {
@@ -183,3 +368,14 @@ func (s *Service) Delete(name string) error {
 	}
 	return nil
 }
+
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return home + strings.TrimPrefix(p, "~")
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mr-gaber/ai-shell/internal/snippets/model"
+)
+
+func TestScriptDropsTrailingComments(t *testing.T) {
+	svc, err := New(filepath.Join(t.TempDir(), "snippets.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.Add("deploy", "make build # compile first\ngit push || echo 'push failed # retry'\nls '#x' # done", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.SetStep("deploy", 1, model.Step{ContinueOnError: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := svc.Script("deploy", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{ make build || true; } && { git push || echo 'push failed # retry'; } && { ls '#x'; }`
+	if got != want {
+		t.Errorf("Script = %s\nwant     %s", got, want)
+	}
+}
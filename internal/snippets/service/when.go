@@ -0,0 +1,294 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A when condition decides whether a step runs:
+//
+//	exit == 0            the most recent step that ran exited 0
+//	exit[2] != 0         step 2 (1-based) ran and did not exit 0
+//	env.CI               $CI is set and non-empty
+//	env.STAGE == prod    $STAGE equals prod (values may be quoted)
+//	success / failure    no earlier step failed / some earlier step failed
+//
+// Terms combine with !, && and || and group with parentheses. exit compares with
+// ==, !=, <, <=, > and >=; a step that did not run matches no comparison.
+type cond interface {
+	eval(st *flowState) bool
+}
+
+// flowState is what conditions can see while a snippet runs.
+type flowState struct {
+	// exits holds each step's exit code, or -1 while it has not run.
+	exits  []int
+	last   int
+	failed bool
+	getenv func(string) string
+}
+
+func newFlowState(steps int) *flowState {
+	st := &flowState{exits: make([]int, steps), last: -1, getenv: os.Getenv}
+	for i := range st.exits {
+		st.exits[i] = -1
+	}
+	return st
+}
+
+func (st *flowState) record(i, exit int) {
+	st.exits[i] = exit
+	st.last = exit
+	if exit != 0 {
+		st.failed = true
+	}
+}
+
+type notCond struct{ c cond }
+
+func (n notCond) eval(st *flowState) bool { return !n.c.eval(st) }
+
+type boolCond struct {
+	and  bool
+	l, r cond
+}
+
+func (b boolCond) eval(st *flowState) bool {
+	if b.and {
+		return b.l.eval(st) && b.r.eval(st)
+	}
+	return b.l.eval(st) || b.r.eval(st)
+}
+
+type outcomeCond struct{ failure bool }
+
+func (o outcomeCond) eval(st *flowState) bool { return st.failed == o.failure }
+
+type exitCond struct {
+	step  int // 1-based; 0 means the most recent step that ran
+	op    string
+	value int
+}
+
+func (e exitCond) eval(st *flowState) bool {
+	code := st.last
+	if e.step > 0 {
+		code = st.exits[e.step-1]
+	}
+	if code < 0 {
+		return false
+	}
+	switch e.op {
+	case "==":
+		return code == e.value
+	case "!=":
+		return code != e.value
+	case "<":
+		return code < e.value
+	case "<=":
+		return code <= e.value
+	case ">":
+		return code > e.value
+	default:
+		return code >= e.value
+	}
+}
+
+type envCond struct {
+	name, op, value string
+}
+
+func (e envCond) eval(st *flowState) bool {
+	v := st.getenv(e.name)
+	switch e.op {
+	case "":
+		return v != ""
+	case "==":
+		return v == e.value
+	default:
+		return v != e.value
+	}
+}
+
+// parseWhen parses a condition for the step at index (0-based).
+func parseWhen(src string, index int) (cond, error) {
+	toks, err := lexWhen(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &whenParser{toks: toks, index: index}
+	c, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("unexpected %q", p.toks[p.pos])
+	}
+	return c, nil
+}
+
+type whenParser struct {
+	toks  []string
+	pos   int
+	index int
+}
+
+func (p *whenParser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+func (p *whenParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *whenParser) or() (cond, error) {
+	l, err := p.and()
+	for err == nil && p.peek() == "||" {
+		p.next()
+		var r cond
+		if r, err = p.and(); err == nil {
+			l = boolCond{l: l, r: r}
+		}
+	}
+	return l, err
+}
+
+func (p *whenParser) and() (cond, error) {
+	l, err := p.unary()
+	for err == nil && p.peek() == "&&" {
+		p.next()
+		var r cond
+		if r, err = p.unary(); err == nil {
+			l = boolCond{and: true, l: l, r: r}
+		}
+	}
+	return l, err
+}
+
+func (p *whenParser) unary() (cond, error) {
+	switch t := p.next(); {
+	case t == "!":
+		c, err := p.unary()
+		return notCond{c}, err
+	case t == "(":
+		c, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		return c, nil
+	case t == "success" || t == "failure":
+		return outcomeCond{failure: t == "failure"}, nil
+	case strings.HasPrefix(t, "env."):
+		name := strings.TrimPrefix(t, "env.")
+		if name == "" {
+			return nil, fmt.Errorf("env. needs a variable name")
+		}
+		if op := p.peek(); op == "==" || op == "!=" {
+			p.next()
+			value := p.next()
+			if value == "" || isOperator(value) {
+				return nil, fmt.Errorf("%s %s needs a value", t, op)
+			}
+			return envCond{name: name, op: op, value: unquote(value)}, nil
+		}
+		return envCond{name: name}, nil
+	case t == "exit" || strings.HasPrefix(t, "exit["):
+		return p.exit(t)
+	case t == "":
+		return nil, fmt.Errorf("unexpected end of condition")
+	default:
+		return nil, fmt.Errorf("unknown term %q (use exit, exit[N], env.NAME, success or failure)", t)
+	}
+}
+
+func (p *whenParser) exit(t string) (cond, error) {
+	c := exitCond{}
+	if t != "exit" {
+		n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(t, "exit["), "]"))
+		if err != nil || !strings.HasSuffix(t, "]") {
+			return nil, fmt.Errorf("bad step reference %q", t)
+		}
+		if n < 1 || n > p.index {
+			return nil, fmt.Errorf("%s must refer to an earlier step (1-%d)", t, p.index)
+		}
+		c.step = n
+	} else if p.index == 0 {
+		return nil, fmt.Errorf("exit refers to the previous step, and step 1 has none")
+	}
+	switch op := p.next(); op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		c.op = op
+	default:
+		return nil, fmt.Errorf("%s needs a comparison such as == 0", t)
+	}
+	v, err := strconv.Atoi(p.next())
+	if err != nil {
+		return nil, fmt.Errorf("%s %s needs an integer", t, c.op)
+	}
+	c.value = v
+	return c, nil
+}
+
+func isOperator(t string) bool {
+	switch t {
+	case "==", "!=", "<", "<=", ">", ">=", "&&", "||", "!", "(", ")":
+		return true
+	}
+	return false
+}
+
+func unquote(v string) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
+// lexWhen splits a condition into operators, quoted strings and words.
+func lexWhen(s string) ([]string, error) {
+	var toks []string
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case strings.HasPrefix(s[i:], "&&"), strings.HasPrefix(s[i:], "||"),
+			strings.HasPrefix(s[i:], "=="), strings.HasPrefix(s[i:], "!="),
+			strings.HasPrefix(s[i:], "<="), strings.HasPrefix(s[i:], ">="):
+			toks = append(toks, s[i:i+2])
+			i += 2
+		case strings.ContainsRune("!()<>", rune(c)):
+			toks = append(toks, string(c))
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote")
+			}
+			toks = append(toks, s[i:i+end+2])
+			i += end + 2
+		default:
+			j := i
+			for j < len(s) && !unicode.IsSpace(rune(s[j])) && !strings.ContainsRune("!()<>=&|\"'", rune(s[j])) {
+				j++
+			}
+			if j == i {
+				return nil, fmt.Errorf("unexpected %q", s[i:i+1])
+			}
+			toks = append(toks, s[i:j])
+			i = j
+		}
+	}
+	return toks, nil
+}
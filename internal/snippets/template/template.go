@@ -0,0 +1,329 @@
+// Package template parses and renders the [[placeholder]] syntax of snippet steps.
+//
+// A placeholder is [[name]], optionally with a type and a default: [[name|type:default]].
+// A backslash directly before it, \[[name]], keeps the text literally and is removed on render.
+// Values rendered into shell command strings are quoted for the position they appear in, so
+// a value is always a single word and never shell syntax. Quoting is tracked into $(...)
+// substitutions; placeholders whose quoting cannot be relied on, inside backticks, $'...'
+// or a # comment, are rejected.
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mr-gaber/ai-shell/internal/utils"
+)
+
+// rx matches a placeholder; groups are the name, the type spec and the default.
+var rx = regexp.MustCompile(`\[\[([A-Za-z_][A-Za-z0-9_]*)(?:\|([^:\]]*))?(?::([^\]]*))?\]\]`)
+
+// Placeholder is one unescaped variable reference. Type and Default are nil when the
+// occurrence does not spell them out.
+type Placeholder struct {
+	Name          string
+	Type, Default *string
+	Start, End    int
+}
+
+// Declares reports whether the occurrence spells out a type or default, as opposed to a bare [[name]].
+func (p Placeholder) Declares() bool {
+	return p.Type != nil || p.Default != nil
+}
+
+// quoting is the shell quoting state at a placeholder.
+type quoting int
+
+const (
+	unquoted quoting = iota
+	singleQuoted
+	doubleQuoted
+	// unsafe marks positions where no quoting keeps a value inert: inside backticks, whose
+	// end is found before quotes are, inside $'...', which interprets backslashes, and
+	// in comments, which a newline in the value would end.
+	unsafe
+)
+
+// frame is one level of command substitution. The quoting inside $(...) starts over,
+// independent of the quotes around it.
+type frame struct {
+	quote    quoting
+	parens   int  // unquoted ( not yet closed inside the frame
+	backtick bool // the frame is a `...` substitution
+	ansi     bool // the single quotes are $'...'
+}
+
+// tracker follows the shell quoting state across the literal text of a template.
+type tracker struct {
+	stack     []frame
+	backslash bool
+	dollar    bool // the previous character was an unescaped $
+	wordStart bool // the next character starts a word, where # opens a comment
+}
+
+func newTracker() *tracker {
+	return &tracker{stack: []frame{{}}, wordStart: true}
+}
+
+// quote reports the quoting for a placeholder at the current position.
+func (t *tracker) quote() quoting {
+	for _, f := range t.stack {
+		if f.backtick {
+			return unsafe
+		}
+	}
+	top := t.stack[len(t.stack)-1]
+	if top.ansi {
+		return unsafe
+	}
+	return top.quote
+}
+
+// inComment reports whether the position is inside a # comment that is not within backticks.
+func (t *tracker) inComment() bool {
+	for _, f := range t.stack {
+		if f.backtick {
+			return false
+		}
+	}
+	return t.stack[len(t.stack)-1].quote == unsafe
+}
+
+// advance moves the state across one character of literal text.
+func (t *tracker) advance(r rune) {
+	top := &t.stack[len(t.stack)-1]
+	dollar, wordStart := t.dollar, t.wordStart
+	t.dollar, t.wordStart = false, false
+
+	if t.backslash {
+		t.backslash = false
+		return
+	}
+	switch top.quote {
+	case unsafe:
+		// A comment runs to the end of the line.
+		if r == '\n' {
+			top.quote = unquoted
+			t.wordStart = true
+		}
+	case singleQuoted:
+		switch {
+		case r == '\\' && top.ansi:
+			t.backslash = true
+		case r == '\'':
+			top.quote, top.ansi = unquoted, false
+		}
+	case doubleQuoted:
+		switch r {
+		case '\\':
+			t.backslash = true
+		case '"':
+			top.quote = unquoted
+		case '$':
+			t.dollar = true
+		case '(':
+			if dollar {
+				t.stack = append(t.stack, frame{})
+				t.wordStart = true
+			}
+		case '`':
+			t.backtick()
+		}
+	default:
+		switch {
+		case r == '\\':
+			t.backslash = true
+		case r == '\'':
+			top.quote, top.ansi = singleQuoted, dollar
+		case r == '"':
+			top.quote = doubleQuoted
+		case r == '$':
+			t.dollar = true
+		case r == '`':
+			t.backtick()
+		case r == '#' && wordStart:
+			top.quote = unsafe
+		case r == '(' && dollar:
+			t.stack = append(t.stack, frame{})
+			t.wordStart = true
+		case r == '(':
+			top.parens++
+			t.wordStart = true
+		case r == ')' && top.parens > 0:
+			top.parens--
+		case r == ')' && len(t.stack) > 1 && !top.backtick:
+			t.stack = t.stack[:len(t.stack)-1]
+		case strings.ContainsRune(" \t\n;&|<>", r):
+			t.wordStart = true
+		}
+	}
+}
+
+// backtick opens a `...` substitution or, inside one, closes it. The closing backtick is
+// found before any quoting inside is considered.
+func (t *tracker) backtick() {
+	for i := len(t.stack) - 1; i > 0; i-- {
+		if t.stack[i].backtick {
+			t.stack = t.stack[:i]
+			return
+		}
+	}
+	t.stack = append(t.stack, frame{backtick: true})
+	t.wordStart = true
+}
+
+// segment is literal text or, when name is set, a variable reference.
+type segment struct {
+	text  string
+	name  string
+	quote quoting
+}
+
+// Template is a parsed snippet string.
+type Template struct {
+	segments []segment
+	vars     []string
+}
+
+// Find returns the unescaped placeholders in s in order.
+func Find(s string) []Placeholder {
+	var out []Placeholder
+	for _, m := range rx.FindAllStringSubmatchIndex(s, -1) {
+		if escaped(s, m[0]) {
+			continue
+		}
+		p := Placeholder{Name: s[m[2]:m[3]], Start: m[0], End: m[1]}
+		if m[4] >= 0 {
+			typ := s[m[4]:m[5]]
+			p.Type = &typ
+		}
+		if m[6] >= 0 {
+			def := s[m[6]:m[7]]
+			p.Default = &def
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// Parse splits s into literal text and placeholders, unescaping \[[...]] and recording the
+// shell quoting state at each placeholder.
+func Parse(s string) Template {
+	var t Template
+	seen := map[string]bool{}
+	state := newTracker()
+	last := 0
+
+	for _, m := range rx.FindAllStringIndex(s, -1) {
+		if escaped(s, m[0]) {
+			// Drop the backslash and keep the placeholder text as a literal.
+			t.literal(s[last:m[0]-1], state)
+			t.literal(s[m[0]:m[1]], state)
+			last = m[1]
+			continue
+		}
+		t.literal(s[last:m[0]], state)
+		name := rx.FindStringSubmatch(s[m[0]:m[1]])[1]
+		t.segments = append(t.segments, segment{name: name, quote: state.quote()})
+		state.wordStart, state.dollar = false, false
+		if !seen[name] {
+			seen[name] = true
+			t.vars = append(t.vars, name)
+		}
+		last = m[1]
+	}
+	t.literal(s[last:], state)
+	return t
+}
+
+// Check reports the first placeholder whose position in a shell command cannot be quoted
+// safely: inside backticks, $'...' or a comment.
+func (t Template) Check() error {
+	for _, seg := range t.segments {
+		if seg.name != "" && seg.quote == unsafe {
+			return fmt.Errorf("[[%s]] is inside backticks, $'...' or a comment, where its value cannot be quoted; use $(...) or plain quotes", seg.name)
+		}
+	}
+	return nil
+}
+
+// StripComment removes a trailing # comment from a one-line shell command, following the
+// same quoting rules as placeholders, so a quoted or mid-word # is kept.
+func StripComment(line string) string {
+	state := newTracker()
+	for i, r := range line {
+		state.advance(r)
+		if state.inComment() {
+			return strings.TrimRight(line[:i], " \t")
+		}
+	}
+	return line
+}
+
+// Vars returns the referenced variable names in order of first use.
+func (t Template) Vars() []string {
+	return t.vars
+}
+
+// Render substitutes values verbatim, as for exec arguments, which are never parsed by a shell.
+func (t Template) Render(values map[string]string) (string, error) {
+	return t.render(values, func(_ segment, v string) string { return v })
+}
+
+// RenderShell substitutes values into a shell command string, quoting each one for its
+// position: unquoted values become one shell word, and values inside single or double
+// quotes are escaped for that quoting. Names in raw are inserted verbatim. It fails for
+// placeholders that Check rejects.
+func (t Template) RenderShell(values map[string]string, raw map[string]bool) (string, error) {
+	if err := t.Check(); err != nil {
+		return "", err
+	}
+	return t.render(values, func(seg segment, v string) string {
+		if raw[seg.name] {
+			return v
+		}
+		switch seg.quote {
+		case singleQuoted:
+			return strings.ReplaceAll(v, "'", `'\''`)
+		case doubleQuoted:
+			return doubleQuoteEscaper.Replace(v)
+		default:
+			return utils.ShellQuote(v)
+		}
+	})
+}
+
+var doubleQuoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+
+func (t Template) render(values map[string]string, format func(segment, string) string) (string, error) {
+	var b strings.Builder
+	for _, seg := range t.segments {
+		if seg.name == "" {
+			b.WriteString(seg.text)
+			continue
+		}
+		v, ok := values[seg.name]
+		if !ok {
+			return "", fmt.Errorf("no value for %s", seg.name)
+		}
+		b.WriteString(format(seg, v))
+	}
+	return b.String(), nil
+}
+
+// literal appends text and advances the quoting state across it.
+func (t *Template) literal(text string, state *tracker) {
+	if text == "" {
+		return
+	}
+	t.segments = append(t.segments, segment{text: text})
+	for _, r := range text {
+		state.advance(r)
+	}
+}
+
+// escaped reports whether the placeholder at i is preceded by a backslash.
+func escaped(s string, i int) bool {
+	return i > 0 && s[i-1] == '\\'
+}
@@ -0,0 +1,105 @@
+package template
+
+import "testing"
+
+func TestRenderShell(t *testing.T) {
+	const value = `a'b"c$(id)`
+	values := map[string]string{"x": value}
+
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{"unquoted", `echo [[x]]`, `echo 'a'\''b"c$(id)'`},
+		{"single quotes", `echo '[[x]]'`, `echo 'a'\''b"c$(id)'`},
+		{"double quotes", `echo "[[x]]"`, `echo "a'b\"c\$(id)"`},
+		{"escaped quote", `echo \"[[x]]`, `echo \"'a'\''b"c$(id)'`},
+		{"quote inside other quotes", `echo "'" [[x]]`, `echo "'" 'a'\''b"c$(id)'`},
+
+		// Quoting starts over inside a command substitution and resumes after it.
+		{"substitution in double quotes", `echo "$(cat [[x]])"`, `echo "$(cat 'a'\''b"c$(id)')"`},
+		{"double quotes in substitution", `echo "$(echo "[[x]]")"`, `echo "$(echo "a'b\"c\$(id)")"`},
+		{"single quotes in substitution", `echo $(echo '[[x]]')`, `echo $(echo 'a'\''b"c$(id)')`},
+		{"after substitution", `echo "$(ls) [[x]]"`, `echo "$(ls) a'b\"c\$(id)"`},
+		{"quoted paren in substitution", `echo "$(echo ")") [[x]]"`, `echo "$(echo ")") a'b\"c\$(id)"`},
+		{"nested parens in substitution", `echo $( (ls) ) "[[x]]"`, `echo $( (ls) ) "a'b\"c\$(id)"`},
+		{"arithmetic", `echo $((1 + [[x]]))`, `echo $((1 + 'a'\''b"c$(id)'))`},
+		{"literal substitution", `echo '$(' [[x]]`, `echo '$(' 'a'\''b"c$(id)'`},
+		{"after ansi quotes", `echo $'\n' [[x]]`, `echo $'\n' 'a'\''b"c$(id)'`},
+		{"after backticks", "echo `date` [[x]]", "echo `date` 'a'\\''b\"c$(id)'"},
+
+		// # only starts a comment at the beginning of a word.
+		{"hash inside a word", `echo a#[[x]]`, `echo a#'a'\''b"c$(id)'`},
+		{"quoted hash", `echo '#' [[x]]`, `echo '#' 'a'\''b"c$(id)'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.tmpl).RenderShell(values, nil)
+			if err != nil {
+				t.Fatalf("RenderShell(%q): %v", tt.tmpl, err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderShell(%q) = %s, want %s", tt.tmpl, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderShellRejectsUnsafePositions(t *testing.T) {
+	values := map[string]string{"x": "v"}
+
+	for _, tmpl := range []string{
+		"echo `cat [[x]]`",
+		"echo \"`cat [[x]]`\"",
+		"echo `echo '[[x]]'`",
+		"echo $(echo `ls [[x]]`)",
+		`echo $'[[x]]'`,
+		`echo $'\' [[x]]'`,
+		`ls # [[x]]`,
+		`ls;#[[x]]`,
+		`echo $(ls # [[x]]`,
+	} {
+		t.Run(tmpl, func(t *testing.T) {
+			p := Parse(tmpl)
+			if err := p.Check(); err == nil {
+				t.Errorf("Check(%q) accepted the placeholder", tmpl)
+			}
+			if got, err := p.RenderShell(values, nil); err == nil {
+				t.Errorf("RenderShell(%q) = %s, want an error", tmpl, got)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	values := map[string]string{"x": `a b'c`}
+
+	got, err := Parse(`--name=[[x]] \[[x]]`).Render(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `--name=a b'c [[x]]`; got != want {
+		t.Errorf("Render = %s, want %s", got, want)
+	}
+	if _, err := Parse(`[[y]]`).Render(values); err == nil {
+		t.Error("Render with a missing value succeeded")
+	}
+}
+
+func TestStripComment(t *testing.T) {
+	tests := []struct{ line, want string }{
+		{`ls -la # list`, `ls -la`},
+		{`ls;# list`, `ls;`},
+		{`# only a comment`, ``},
+		{`echo '#' "#" \# a#b`, `echo '#' "#" \# a#b`},
+		{`echo "$(echo '# x')" # note`, `echo "$(echo '# x')"`},
+		{"echo `ls # inside` done", "echo `ls # inside` done"},
+		{`echo $'\'#' # note`, `echo $'\'#'`},
+	}
+	for _, tt := range tests {
+		if got := StripComment(tt.line); got != tt.want {
+			t.Errorf("StripComment(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
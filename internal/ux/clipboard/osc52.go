@@ -0,0 +1,32 @@
+// Package clipboard copies text to the user's clipboard through the terminal.
+package clipboard
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Copy writes an OSC 52 sequence to the controlling terminal, which most modern terminals
+// (and tmux with set-clipboard on) turn into a clipboard write. This also works over SSH.
+func Copy(text string) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return write(os.Stderr, text)
+	}
+	defer tty.Close()
+	return write(tty, text)
+}
+
+func write(w io.Writer, text string) error {
+	seq := "\033]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if os.Getenv("TMUX") != "" {
+		// tmux only forwards escape sequences wrapped in its passthrough envelope.
+		seq = "\033Ptmux;\033" + seq + "\033\\"
+	}
+	if _, err := io.WriteString(w, seq); err != nil {
+		return fmt.Errorf("write clipboard sequence: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package markdown
+
+import (
+	"strings"
+	"unicode"
+)
+
+// syntax describes just enough of a language to colour keywords, strings, comments and numbers.
+type syntax struct {
+	keywords map[string]bool
+	comment  string
+	flags    bool
+}
+
+var languages = map[string]syntax{
+	"shell": {
+		keywords: words("if then else elif fi for while until do done case esac in function return local export unset source sudo"),
+		comment:  "#",
+		flags:    true,
+	},
+	"go": {
+		keywords: words("package import func return if else for range switch case default var const type struct interface map chan go defer select break continue nil true false"),
+		comment:  "//",
+	},
+	"python": {
+		keywords: words("def class import from return if elif else for while in not and or is with as try except finally raise lambda yield pass None True False"),
+		comment:  "#",
+	},
+	"js": {
+		keywords: words("function const let var return if else for while of in new class import from export default async await try catch throw null undefined true false"),
+		comment:  "//",
+	},
+	"data": {comment: "#"},
+}
+
+var aliases = map[string]string{
+	"": "shell", "sh": "shell", "bash": "shell", "zsh": "shell", "shell": "shell", "console": "shell", "shell-session": "shell",
+	"go": "go", "golang": "go",
+	"py": "python", "python": "python",
+	"js": "js", "javascript": "js", "ts": "js", "typescript": "js",
+	"json": "data", "yaml": "data", "yml": "data", "toml": "data", "ini": "data",
+}
+
+func words(s string) map[string]bool {
+	m := map[string]bool{}
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+// highlight colours one line of code. Unknown languages fall back to the generic data syntax.
+func highlight(lang, line string, color bool) string {
+	if !color {
+		return line
+	}
+	name, ok := aliases[strings.ToLower(lang)]
+	if !ok {
+		name = "data"
+	}
+	syn := languages[name]
+
+	var b strings.Builder
+	rs := []rune(line)
+	for i := 0; i < len(rs); {
+		c := rs[i]
+		switch {
+		case syn.comment != "" && strings.HasPrefix(string(rs[i:]), syn.comment) && (i == 0 || unicode.IsSpace(rs[i-1])):
+			b.WriteString(ansiFaint + string(rs[i:]) + ansiReset)
+			return b.String()
+		case c == '"' || c == '\'' || c == '`':
+			j := i + 1
+			for j < len(rs) && rs[j] != c {
+				if rs[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(rs))
+			b.WriteString(ansiGreen + string(rs[i:j]) + ansiReset)
+			i = j
+		case unicode.IsDigit(c) && (i == 0 || !isIdent(rs[i-1])):
+			j := i
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.') {
+				j++
+			}
+			b.WriteString(ansiYellow + string(rs[i:j]) + ansiReset)
+			i = j
+		case syn.flags && c == '-' && (i == 0 || unicode.IsSpace(rs[i-1])):
+			j := i
+			for j < len(rs) && !unicode.IsSpace(rs[j]) && rs[j] != '=' {
+				j++
+			}
+			b.WriteString(ansiCyan + string(rs[i:j]) + ansiReset)
+			i = j
+		case isIdent(c):
+			j := i
+			for j < len(rs) && isIdent(rs[j]) {
+				j++
+			}
+			word := string(rs[i:j])
+			if syn.keywords[word] {
+				word = ansiMagenta + word + ansiReset
+			}
+			b.WriteString(word)
+			i = j
+		default:
+			b.WriteRune(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+func isIdent(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
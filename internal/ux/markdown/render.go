@@ -0,0 +1,216 @@
+// Package markdown renders the subset of markdown that model answers use for display in a terminal.
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Options controls terminal rendering.
+type Options struct {
+	// Width is the column count paragraphs and list items are wrapped to. Code blocks are never wrapped.
+	Width int
+	// Color enables ANSI styling. Without it, structure is kept and inline code keeps its backticks.
+	Color bool
+}
+
+// Block is a fenced code block.
+type Block struct {
+	Lang string
+	Code string
+}
+
+const (
+	ansiReset     = "\033[0m"
+	ansiBold      = "\033[1m"
+	ansiFaint     = "\033[2m"
+	ansiUnderline = "\033[4m"
+	ansiGreen     = "\033[32m"
+	ansiYellow    = "\033[33m"
+	ansiMagenta   = "\033[35m"
+	ansiCyan      = "\033[36m"
+)
+
+var (
+	headingRE = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletRE  = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	orderedRE = regexp.MustCompile(`^(\s*)(\d+[.)])\s+(.*)$`)
+	ruleRE    = regexp.MustCompile(`^\s*(-\s*){3,}$|^\s*(\*\s*){3,}$|^\s*(_\s*){3,}$`)
+	fenceRE   = regexp.MustCompile("^\\s*(```+|~~~+)\\s*([\\w+#.-]*)")
+	codeRE    = regexp.MustCompile("`([^`]+)`")
+	boldRE    = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	ansiRE    = regexp.MustCompile("\033\\[[0-9;]*m")
+)
+
+// Render formats src for a terminal.
+func Render(src string, opts Options) string {
+	if opts.Width <= 0 {
+		opts.Width = 80
+	}
+	r := renderer{opts: opts}
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := fenceRE.FindStringSubmatch(line); m != nil {
+			end := i + 1
+			for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), m[1]) {
+				end++
+			}
+			r.code(m[2], lines[i+1:min(end, len(lines))])
+			i = end
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			r.flush()
+			r.blank()
+		case headingRE.MatchString(line):
+			r.flush()
+			m := headingRE.FindStringSubmatch(line)
+			r.heading(len(m[1]), m[2])
+		case ruleRE.MatchString(line):
+			r.flush()
+			r.emit(r.style(strings.Repeat("─", min(r.opts.Width, 40)), ansiFaint))
+		case strings.HasPrefix(trimmed, ">"):
+			r.flush()
+			r.wrap(strings.TrimSpace(strings.TrimPrefix(trimmed, ">")), r.style("│ ", ansiFaint), r.style("│ ", ansiFaint))
+		case bulletRE.MatchString(line):
+			r.flush()
+			m := bulletRE.FindStringSubmatch(line)
+			indent := strings.Repeat(" ", len(expandTabs(m[1])))
+			r.wrap(m[2], indent+"• ", indent+"  ")
+		case orderedRE.MatchString(line):
+			r.flush()
+			m := orderedRE.FindStringSubmatch(line)
+			indent := strings.Repeat(" ", len(expandTabs(m[1])))
+			r.wrap(m[3], indent+m[2]+" ", indent+strings.Repeat(" ", len(m[2])+1))
+		default:
+			r.para = append(r.para, trimmed)
+		}
+	}
+	r.flush()
+	return strings.TrimRight(r.out.String(), "\n") + "\n"
+}
+
+// CodeBlocks returns the fenced code blocks in src in order of appearance.
+func CodeBlocks(src string) []Block {
+	var blocks []Block
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		m := fenceRE.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		end := i + 1
+		for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), m[1]) {
+			end++
+		}
+		blocks = append(blocks, Block{Lang: strings.ToLower(m[2]), Code: strings.Join(lines[i+1:min(end, len(lines))], "\n")})
+		i = end
+	}
+	return blocks
+}
+
+type renderer struct {
+	opts Options
+	out  strings.Builder
+	para []string
+}
+
+func (r *renderer) emit(line string) {
+	r.out.WriteString(strings.ReplaceAll(line, codeSpace, " "))
+	r.out.WriteByte('\n')
+}
+
+// blank emits at most one empty line in a row.
+func (r *renderer) blank() {
+	s := r.out.String()
+	if s == "" || strings.HasSuffix(s, "\n\n") {
+		return
+	}
+	r.out.WriteByte('\n')
+}
+
+// flush wraps the pending paragraph; soft line breaks inside it are joined with spaces.
+func (r *renderer) flush() {
+	if len(r.para) == 0 {
+		return
+	}
+	r.wrap(strings.Join(r.para, " "), "", "")
+	r.para = nil
+}
+
+func (r *renderer) heading(level int, text string) {
+	r.blank()
+	text = r.inline(text)
+	switch level {
+	case 1:
+		r.emit(r.style(text, ansiBold+ansiUnderline+ansiCyan))
+	case 2:
+		r.emit(r.style(text, ansiBold+ansiCyan))
+	default:
+		r.emit(r.style(text, ansiBold))
+	}
+}
+
+func (r *renderer) code(lang string, lines []string) {
+	r.blank()
+	for _, ln := range lines {
+		r.emit("    " + highlight(lang, expandTabs(ln), r.opts.Color))
+	}
+	r.blank()
+}
+
+// wrap writes text word-wrapped to the width, starting with first and indenting continuation lines with rest.
+func (r *renderer) wrap(text, first, rest string) {
+	words := strings.Fields(r.inline(text))
+	prefix := first
+	line := ""
+	for _, w := range words {
+		if line != "" && visibleLen(prefix)+visibleLen(line)+1+visibleLen(w) > r.opts.Width {
+			r.emit(prefix + line)
+			prefix, line = rest, ""
+		}
+		if line == "" {
+			line = w
+		} else {
+			line += " " + w
+		}
+	}
+	r.emit(prefix + line)
+}
+
+// codeSpace stands in for spaces inside code spans so wrapping never splits them.
+const codeSpace = "\x00"
+
+// inline styles code spans and bold text.
+func (r *renderer) inline(s string) string {
+	s = codeRE.ReplaceAllStringFunc(s, func(span string) string {
+		return strings.ReplaceAll(span, " ", codeSpace)
+	})
+	if !r.opts.Color {
+		return boldRE.ReplaceAllString(s, "$1$2")
+	}
+	s = codeRE.ReplaceAllString(s, ansiCyan+"$1"+ansiReset)
+	return boldRE.ReplaceAllString(s, ansiBold+"$1$2"+ansiReset)
+}
+
+func (r *renderer) style(s, code string) string {
+	if !r.opts.Color {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+func visibleLen(s string) int {
+	return utf8.RuneCountInString(ansiRE.ReplaceAllString(s, ""))
+}
+
+func expandTabs(s string) string {
+	return strings.ReplaceAll(s, "\t", "    ")
+}
@@ -0,0 +1,106 @@
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mr-gaber/ai-shell/internal/errs"
+)
+
+// Format selects how the printer renders command output.
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+// ParseFormat validates an --output value.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case FormatText, FormatJSON:
+		return f, nil
+	}
+	return "", errs.New("output-format", fmt.Sprintf("[aish] unknown output format %q (expected text or json)", s))
+}
+
+// SetFormat switches the printer between human text and JSON output.
+// In JSON mode stdout carries exactly one JSON document per command: its result or its error.
+// Informational messages and prompts move to stderr.
+func (p *Printer) SetFormat(f Format) {
+	p.format = f
+	if f == FormatJSON {
+		p.color = false
+	}
+}
+
+// JSON reports whether commands should emit their results as JSON. It is safe on a nil printer.
+func (p *Printer) JSON() bool {
+	return p != nil && p.format == FormatJSON
+}
+
+// Result writes v as the command's JSON result.
+func (p *Printer) Result(v any) {
+	p.encode(jsonEnvelope{OK: true, Result: v})
+}
+
+// Failure writes err as the command's JSON error together with v, the part of the result
+// that was produced before it failed.
+func (p *Printer) Failure(v any, err error) {
+	p.encode(jsonEnvelope{Result: v, Error: jsonErrorOf(err)})
+}
+
+// Prompt writes a question without a trailing newline, on stderr in JSON mode. It is safe on a nil printer.
+func (p *Printer) Prompt(msg string) {
+	if p == nil {
+		fmt.Print(msg)
+		return
+	}
+	_, _ = io.WriteString(p.messages(), msg)
+}
+
+// jsonEnvelope is the top-level shape of every JSON document the printer emits.
+type jsonEnvelope struct {
+	OK     bool       `json:"ok"`
+	Result any        `json:"result,omitempty"`
+	Error  *jsonError `json:"error,omitempty"`
+}
+
+type jsonError struct {
+	Code     string            `json:"code,omitempty"`
+	Message  string            `json:"message"`
+	Severity errs.Severity     `json:"severity"`
+	Fields   map[string]string `json:"fields,omitempty"`
+}
+
+func (p *Printer) errorJSON(err error) {
+	p.encode(jsonEnvelope{Error: jsonErrorOf(err)})
+}
+
+func jsonErrorOf(err error) *jsonError {
+	je := &jsonError{Message: strings.TrimSpace(err.Error()), Severity: errs.SeverityError}
+	if enriched, ok := errs.From(err); ok {
+		je.Code = enriched.Code()
+		je.Severity = enriched.Severity()
+		je.Fields = enriched.Fields()
+	}
+	return je
+}
+
+func (p *Printer) encode(v jsonEnvelope) {
+	enc := json.NewEncoder(p.out)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		p.write(p.err, fmt.Sprintf("[error] cannot encode output: %v", err))
+	}
+}
+
+// messages is where human-readable lines go: stdout normally, stderr in JSON mode.
+func (p *Printer) messages() io.Writer {
+	if p.format == FormatJSON {
+		return p.err
+	}
+	return p.out
+}
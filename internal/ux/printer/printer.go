@@ -8,21 +8,25 @@ import (
 	"strings"
 
 	"github.com/mr-gaber/ai-shell/internal/errs"
+	"github.com/mr-gaber/ai-shell/internal/ux/markdown"
 	"golang.org/x/term"
 )
 
 // Printer handles user-facing output with optional color formatting.
 type Printer struct {
-	out   io.Writer
-	err   io.Writer
-	color bool
+	out    io.Writer
+	err    io.Writer
+	color  bool
+	tty    bool
+	format Format
 }
 
 // New constructs a printer writing to the provided io.Writers.
 // Color output is enabled only when the destination is a TTY and NO_COLOR/AISH_NO_COLOR are unset.
 func New(out, err io.Writer) *Printer {
-	p := &Printer{out: out, err: err}
-	p.color = shouldColor(out) || shouldColor(err)
+	p := &Printer{out: out, err: err, format: FormatText}
+	p.tty = shouldColor(out)
+	p.color = p.tty || shouldColor(err)
 	if noColorEnv() {
 		p.color = false
 	}
@@ -34,6 +38,10 @@ func (p *Printer) Error(err error) {
 	if err == nil {
 		return
 	}
+	if p.format == FormatJSON {
+		p.errorJSON(err)
+		return
+	}
 
 	label := "error"
 	code := ""
@@ -85,7 +93,7 @@ func (p *Printer) Warn(msg string) {
 	if strings.TrimSpace(msg) == "" {
 		return
 	}
-	p.write(p.out, p.applyColor(fmt.Sprintf("[warn] %s", msg), colorYellow))
+	p.write(p.messages(), p.applyColor(fmt.Sprintf("[warn] %s", msg), colorYellow))
 }
 
 // Info prints an informational message.
@@ -93,7 +101,20 @@ func (p *Printer) Info(msg string) {
 	if strings.TrimSpace(msg) == "" {
 		return
 	}
-	p.write(p.out, msg)
+	p.write(p.messages(), msg)
+}
+
+// Markdown renders a model answer for the terminal: headings, lists, highlighted code blocks and
+// wrapping to the terminal width. Output that is not a terminal gets the raw markdown.
+func (p *Printer) Markdown(msg string) {
+	if strings.TrimSpace(msg) == "" {
+		return
+	}
+	if !p.tty || p.format == FormatJSON {
+		p.Info(msg)
+		return
+	}
+	p.write(p.out, markdown.Render(msg, markdown.Options{Width: termWidth(p.out), Color: p.color}))
 }
 
 // Success prints a success message.
@@ -101,7 +122,7 @@ func (p *Printer) Success(msg string) {
 	if strings.TrimSpace(msg) == "" {
 		return
 	}
-	p.write(p.out, p.applyColor(msg, colorGreen))
+	p.write(p.messages(), p.applyColor(msg, colorGreen))
 }
 
 func (p *Printer) write(dst io.Writer, msg string) {
@@ -126,6 +147,15 @@ func shouldColor(w io.Writer) bool {
 	return term.IsTerminal(int(f.Fd()))
 }
 
+func termWidth(w io.Writer) int {
+	if f, ok := w.(*os.File); ok {
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil && width > 0 {
+			return width
+		}
+	}
+	return 80
+}
+
 func noColorEnv() bool {
 	for _, k := range []string{"NO_COLOR", "AISH_NO_COLOR"} {
 		if v := strings.TrimSpace(os.Getenv(k)); v != "" {